@@ -0,0 +1,390 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"pradytpk/go-terraform-ai/pkg/session"
+	"pradytpk/go-terraform-ai/pkg/terraform"
+	"pradytpk/go-terraform-ai/pkg/utils"
+	"sort"
+	"strings"
+	"time"
+
+	azureopenai "pradytpk/go-terraform-ai/pkg/gpt3"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/pkg/errors"
+)
+
+// Roles and types of the tool calling messages
+const (
+	systemRole       = "system"
+	toolRole         = "tool"
+	functionRole     = "function"
+	toolTypeFunction = "function"
+)
+
+const (
+	// agentInstructions is the system message of the agent loop
+	agentInstructions = "You can call read-only tools to inspect the terraform project: its files, the validation result, " +
+		"the resources in the state and the provider schemas. Use them instead of guessing resource names, attributes " +
+		"or what already exists, then answer the request in the requested format."
+	// maxToolResult is the number of characters of a tool result sent to the model
+	maxToolResult = 8000
+	// maxListedFiles is the number of files list_files returns
+	maxListedFiles = 200
+	// minAnswerTokens is the token budget under which the model must answer instead of calling tools
+	minAnswerTokens = 1024
+)
+
+var (
+	// errAgent is returned when the agent loop ends without an answer
+	errAgent = errors.New("agent failed")
+	// errToolCall is returned to the model for calls of unknown tools or with invalid arguments
+	errToolCall = errors.New("invalid tool call")
+)
+
+// agentTool is a read-only tool the model can call
+type agentTool struct {
+	name        string
+	description string
+	// params maps the string parameters to their description
+	params   map[string]string
+	required []string
+	run      func(ctx context.Context, args map[string]string) (string, error)
+}
+
+// agentStep is a line of the agent transcript
+type agentStep struct {
+	Time      time.Time `json:"time"`
+	Step      int       `json:"step"`
+	Tool      string    `json:"tool,omitempty"`
+	Arguments string    `json:"arguments,omitempty"`
+	Result    string    `json:"result,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Answer    string    `json:"answer,omitempty"`
+}
+
+// agentTools returns the tools of the agent loop, they only read the working dir and the state
+//
+//	@return []agentTool
+func agentTools() []agentTool {
+	var schemas *tfjson.ProviderSchemas
+	return []agentTool{
+		{
+			name:        "list_files",
+			description: "List the files of the terraform project, or of a directory of it. Hidden directories such as .terraform are skipped.",
+			params:      map[string]string{"dir": "A directory relative to the project root, empty for the root."},
+			run:         listFilesTool,
+		},
+		{
+			name:        "read_file",
+			description: "Read a .tf or .tf.json file of the terraform project. Credentials in it are redacted.",
+			params:      map[string]string{"path": "The file path relative to the project root."},
+			required:    []string{"path"},
+			run:         readFileTool,
+		},
+		{
+			name:        "terraform_validate",
+			description: "Run terraform validate on the project and return the problems found.",
+			run: func(ctx context.Context, _ map[string]string) (string, error) {
+				if err := createOps(); err != nil {
+					return "", err
+				}
+				out, err := ops.Validate(ctx)
+				if err != nil {
+					return "", err
+				}
+				if out.Valid {
+					return "the configuration is valid", nil
+				}
+				redacted, _ := utils.RedactSecrets(terraform.FormatDiagnostics(out.Diagnostics))
+				return redacted, nil
+			},
+		},
+		{
+			name:        "list_state",
+			description: "List the addresses of the resources and data sources in the terraform state.",
+			run: func(ctx context.Context, _ map[string]string) (string, error) {
+				if err := createOps(); err != nil {
+					return "", err
+				}
+				state, err := ops.State(ctx)
+				if err != nil {
+					return "", err
+				}
+				addresses := terraform.StateAddresses(state)
+				if len(addresses) == 0 {
+					return "the state is empty", nil
+				}
+				// resource keys come from the configuration and may hold credentials
+				redacted, _ := utils.RedactSecrets(strings.Join(addresses, "\n"))
+				return redacted, nil
+			},
+		},
+		{
+			name:        "provider_schema",
+			description: "Return the attributes and nested blocks of a resource type of the initialized providers, e.g. azurerm_storage_account.",
+			params:      map[string]string{"resource_type": "The resource type."},
+			required:    []string{"resource_type"},
+			run: func(ctx context.Context, args map[string]string) (string, error) {
+				// the schemas of every provider come at once, they are loaded on the first call
+				if schemas == nil {
+					if err := createOps(); err != nil {
+						return "", err
+					}
+					s, err := ops.ProviderSchemas(ctx)
+					if err != nil {
+						return "", err
+					}
+					schemas = s
+				}
+				schema, err := terraform.FormatResourceSchema(schemas, args["resource_type"])
+				if err != nil {
+					return "", err
+				}
+				redacted, _ := utils.RedactSecrets(schema)
+				return redacted, nil
+			},
+		},
+	}
+}
+
+// listFilesTool lists the files under a directory of the working dir
+//
+//	@param _
+//	@param args
+//	@return string
+//	@return error
+func listFilesTool(_ context.Context, args map[string]string) (string, error) {
+	root, err := projectPath(args["dir"])
+	if err != nil {
+		return "", err
+	}
+	var files []string
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && path != root && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() {
+			rel, _ := filepath.Rel(*workingDir, path)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("error listing files:%w", err)
+	}
+	sort.Strings(files)
+	if len(files) > maxListedFiles {
+		files = append(files[:maxListedFiles], fmt.Sprintf("... %d more files", len(files)-maxListedFiles))
+	}
+	if len(files) == 0 {
+		return "no files", nil
+	}
+	return strings.Join(files, "\n"), nil
+}
+
+// readFileTool reads a terraform file of the working dir with its credentials redacted
+//
+//	@param _
+//	@param args
+//	@return string
+//	@return error
+func readFileTool(_ context.Context, args map[string]string) (string, error) {
+	if !strings.HasSuffix(args["path"], ".tf") && !strings.HasSuffix(args["path"], ".tf.json") {
+		return "", errors.Wrapf(errToolCall, "%s is not a .tf or .tf.json file", args["path"])
+	}
+	path, err := projectPath(args["path"])
+	if err != nil {
+		return "", err
+	}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading file:%w", err)
+	}
+	redacted, _ := utils.RedactSecrets(string(src))
+	return redacted, nil
+}
+
+// projectPath resolves a path relative to the working dir, paths leaving it, also through symlinks, are rejected
+//
+//	@param rel
+//	@return string
+//	@return error
+func projectPath(rel string) (string, error) {
+	path := filepath.Join(*workingDir, filepath.FromSlash(rel))
+	if !inside(*workingDir, path) {
+		return "", errors.Wrapf(errToolCall, "%s is outside the project", rel)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		// a missing path fails when it is read
+		return path, nil
+	}
+	if root, err := filepath.EvalSymlinks(*workingDir); err == nil && !inside(root, resolved) {
+		return "", errors.Wrapf(errToolCall, "%s links outside the project", rel)
+	}
+	return path, nil
+}
+
+// inside reports whether path is dir or under it
+//
+//	@param dir
+//	@param path
+//	@return bool
+func inside(dir string, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// agentCompletion answers the prompt with a chat model that may first call the read-only tools,
+// for at most --agent-steps rounds of calls
+//
+//	@receiver c
+//	@param ctx
+//	@param prompt
+//	@param maxTokens
+//	@param temp
+//	@return string
+//	@return error
+func (c *oaiClients) agentCompletion(ctx context.Context, prompt strings.Builder, maxTokens *int, temp float32) (string, error) {
+	tools := agentTools()
+	definitions := make([]azureopenai.Tool, 0, len(tools))
+	byName := make(map[string]agentTool, len(tools))
+	for _, t := range tools {
+		properties := make(map[string]azureopenai.ToolProperty, len(t.params))
+		for name, description := range t.params {
+			properties[name] = azureopenai.ToolProperty{Type: "string", Description: description}
+		}
+		definitions = append(definitions, azureopenai.Tool{
+			Type: toolTypeFunction,
+			Function: azureopenai.ToolFunction{
+				Name:        t.name,
+				Description: t.description,
+				Parameters:  azureopenai.ToolParameters{Type: "object", Properties: properties, Required: t.required},
+			},
+		})
+		byName[t.name] = t
+	}
+
+	messages := []azureopenai.ChatCompletionRequestMessage{
+		{Role: systemRole, Content: agentInstructions},
+		{Role: userRole, Content: prompt.String()},
+	}
+	budget := *maxTokens
+	for step := 1; ; step++ {
+		offered := definitions
+		// out of steps or tokens, the model must answer with what it has
+		if step > *agentSteps || budget < minAnswerTokens {
+			offered = nil
+		}
+		reply, err := c.toolChat(ctx, messages, offered, budget, temp)
+		if err != nil {
+			return "", err
+		}
+		if len(reply.ToolCalls) == 0 {
+			return reply.Content, writeTranscript(agentStep{Time: time.Now(), Step: step, Answer: reply.Content})
+		}
+		if offered == nil {
+			return "", errors.Wrapf(errAgent, "no answer after %d steps", *agentSteps)
+		}
+
+		messages = append(messages, azureopenai.ChatCompletionRequestMessage{Role: reply.Role, Content: reply.Content, ToolCalls: reply.ToolCalls})
+		for _, call := range reply.ToolCalls {
+			result, err := runTool(ctx, byName, call)
+			log.Printf("🔧 %s %s\n", call.Function.Name, call.Function.Arguments)
+			recordEvent(session.EventTool, call.Function.Name+" "+call.Function.Arguments, err)
+			entry := agentStep{Time: time.Now(), Step: step, Tool: call.Function.Name, Arguments: call.Function.Arguments, Result: result}
+			if err != nil {
+				// the model gets the error to correct its call
+				entry.Error = err.Error()
+				result = "error: " + err.Error()
+			}
+			// each step is written as it happens, a failed or interrupted run keeps its transcript
+			if err = writeTranscript(entry); err != nil {
+				return "", err
+			}
+			messages = append(messages, azureopenai.ChatCompletionRequestMessage{Role: toolRole, ToolCallID: call.ID, Content: result})
+			// about 3 characters a token
+			budget -= len(result) / 3
+		}
+	}
+}
+
+// toolChat sends the conversation and the tools to the OpenAI or the Azure API
+//
+//	@receiver c
+//	@param ctx
+//	@param messages
+//	@param tools
+//	@param maxTokens
+//	@param temp
+//	@return azureopenai.ChatCompletionResponseMessage
+//	@return error
+func (c *oaiClients) toolChat(ctx context.Context, messages []azureopenai.ChatCompletionRequestMessage, tools []azureopenai.Tool, maxTokens int, temp float32) (azureopenai.ChatCompletionResponseMessage, error) {
+	if *azureOpenAIEndpoint == "" {
+		return c.openaiToolChat(ctx, messages, tools, maxTokens, temp)
+	}
+	return c.azureToolChat(ctx, messages, tools, maxTokens, temp)
+}
+
+// runTool runs a tool call, the result is truncated to maxToolResult characters
+//
+//	@param ctx
+//	@param tools
+//	@param call
+//	@return string
+//	@return error
+func runTool(ctx context.Context, tools map[string]agentTool, call azureopenai.ToolCall) (string, error) {
+	tool, ok := tools[call.Function.Name]
+	if !ok {
+		return "", errors.Wrapf(errToolCall, "unknown tool %q", call.Function.Name)
+	}
+	args := map[string]string{}
+	if strings.TrimSpace(call.Function.Arguments) != "" {
+		if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+			return "", errors.Wrapf(errToolCall, "invalid arguments of %s: %s", tool.name, err)
+		}
+	}
+	for _, name := range tool.required {
+		if args[name] == "" {
+			return "", errors.Wrapf(errToolCall, "%s needs %s", tool.name, name)
+		}
+	}
+	result, err := tool.run(ctx, args)
+	if err != nil {
+		return "", err
+	}
+	if len(result) > maxToolResult {
+		result = result[:maxToolResult] + "\n... truncated"
+	}
+	return result, nil
+}
+
+// writeTranscript appends a step of the agent loop to the --agent-transcript file as a JSON line
+//
+//	@param step
+//	@return error
+func writeTranscript(step agentStep) error {
+	if *agentTranscript == "" {
+		return nil
+	}
+	f, err := os.OpenFile(*agentTranscript, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("error opening agent transcript:%w", err)
+	}
+	defer f.Close()
+	if err = json.NewEncoder(f).Encode(step); err != nil {
+		return fmt.Errorf("error writing agent transcript:%w", err)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"pradytpk/go-terraform-ai/pkg/audit"
+	"pradytpk/go-terraform-ai/pkg/terraform"
+	"pradytpk/go-terraform-ai/pkg/utils"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// Values of the --audit-prompt flag
+const (
+	auditPromptRedact = "redact"
+	auditPromptFull   = "full"
+	auditPromptHash   = "hash"
+)
+
+var (
+	// auditLog is opened on the first audited event
+	auditLog *audit.Logger
+	// auditCommand is the name of the running command written into the entries
+	auditCommand string
+	// auditHead is the head hash the audit log must still contain
+	auditHead string
+)
+
+// addAudit
+//
+//	@return *cobra.Command
+func addAudit() *cobra.Command {
+	auditCmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Work with the audit log of prompts, generated files, plans and applies",
+		// the audit log needs no terraform
+		PersistentPreRunE: preRun,
+	}
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Verify the hash chain of the audit log to detect changed, removed or reordered entries",
+		Args:  cobra.NoArgs,
+		RunE:  auditVerifyCommand,
+	}
+	verifyCmd.Flags().StringVar(&auditHead, "head", "", "A head hash printed by an earlier verify, to detect a truncated or rewritten log.")
+	auditCmd.AddCommand(verifyCmd)
+	return auditCmd
+}
+
+// auditVerifyCommand is a function that handles the "audit verify" command in the CLI
+//
+//	@param _
+//	@param _
+//	@return error
+func auditVerifyCommand(_ *cobra.Command, _ []string) error {
+	head, err := audit.Verify(auditPath(), auditHead)
+	if err != nil {
+		return fmt.Errorf("audit log %s is not valid: %w", auditPath(), err)
+	}
+	log.Printf("✅ %s: %d entries, hash chain intact\n", auditPath(), head.Seq)
+	// the head is printed alone on stdout so it can be kept outside of the log
+	log.Printf("Head of the chain, keep it to verify later with --head:\n")
+	fmt.Println(head.Hash)
+	return nil
+}
+
+// auditPath returns the path of the audit log, by default in the working dir
+//
+//	@return string
+func auditPath() string {
+	if *auditLogPath != "" {
+		return *auditLogPath
+	}
+	return filepath.Join(*workingDir, audit.DefaultPath)
+}
+
+// auditEvent appends the entry to the audit log, opening it on first use
+//
+//	@param e
+//	@return error
+func auditEvent(e audit.Entry) error {
+	if auditLog == nil {
+		l, err := audit.Open(auditPath())
+		if err != nil {
+			return fmt.Errorf("error opening audit log: %w", err)
+		}
+		auditLog = l
+	}
+	e.Command = auditCommand
+	e.Model = modelName(*openAIDeploymentName)
+	if *azureOpenAIEndpoint != "" {
+		e.Deployment = *openAIDeploymentName
+	}
+	if err := auditLog.Log(e); err != nil {
+		return fmt.Errorf("error writing audit log: %w", err)
+	}
+	return nil
+}
+
+// auditPrompt records a prompt sent to the model and its response, according to --audit-prompt
+//
+//	@param prompt
+//	@param response
+//	@return error
+func auditPrompt(prompt string, response string) error {
+	e := audit.Entry{Event: audit.EventPrompt, PromptSHA256: audit.SHA256(prompt)}
+	switch *auditPromptMode {
+	case auditPromptFull:
+		e.Prompt, e.Response = prompt, response
+	case auditPromptHash:
+		e.ResponseSHA256 = audit.SHA256(response)
+	case auditPromptRedact:
+		e.Prompt, _ = utils.RedactSecrets(prompt)
+		e.Response, _ = utils.RedactSecrets(response)
+	default:
+		return errors.Wrapf(errFlag, "unknown audit-prompt %q", *auditPromptMode)
+	}
+	return auditEvent(e)
+}
+
+// auditPlan records the summary of the plan about to be applied, an audit failure stops the apply
+//
+//	@param plan
+//	@return error
+func auditPlan(plan *tfjson.Plan) error {
+	return auditEvent(audit.Entry{Event: audit.EventPlan, Plan: terraform.FormatChanges(terraform.SummarizePlan(plan))})
+}
+
+// auditResult records the result of an init or apply
+//
+//	@param event
+//	@param opErr
+//	@return error
+func auditResult(event string, opErr error) error {
+	e := audit.Entry{Event: event, Result: audit.ResultSuccess}
+	if opErr != nil {
+		e.Result, e.Error = audit.ResultFailure, opErr.Error()
+	}
+	return auditEvent(e)
+}
+
+// applyTerraform applies the configuration and records the result in the audit log
+//
+//	@param ctx
+//	@return error
+func applyTerraform(ctx context.Context) error {
+	err := ops.Apply(ctx)
+	if auditErr := auditResult(audit.EventApply, err); auditErr != nil {
+		if err != nil {
+			return fmt.Errorf("%w (%s)", err, auditErr)
+		}
+		return auditErr
+	}
+	return err
+}
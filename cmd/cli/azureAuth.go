@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"os"
+	azureopenai "pradytpk/go-terraform-ai/pkg/gpt3"
+
+	"github.com/pkg/errors"
+)
+
+// Values of the --azure-auth flag
+const (
+	azureAuthKey              = "key"
+	azureAuthClientSecret     = "client-secret"
+	azureAuthWorkloadIdentity = "workload-identity"
+	azureAuthManagedIdentity  = "managed-identity"
+)
+
+// azureTokenProvider returns the Entra ID token provider of --azure-auth, nil for key authentication.
+// Secrets are only read from the environment: AZURE_CLIENT_SECRET and AZURE_FEDERATED_TOKEN_FILE.
+//
+//	@return azureopenai.TokenProvider
+//	@return error
+func azureTokenProvider() (azureopenai.TokenProvider, error) {
+	switch *azureAuth {
+	case azureAuthKey:
+		return nil, nil
+	case azureAuthClientSecret:
+		secret := os.Getenv("AZURE_CLIENT_SECRET")
+		if *azureTenantID == "" || *azureClientID == "" || secret == "" {
+			return nil, errors.Wrap(errFlag, "client-secret auth needs --azure-tenant-id, --azure-client-id and AZURE_CLIENT_SECRET")
+		}
+		return azureopenai.NewClientSecretCredential(*azureTenantID, *azureClientID, secret), nil
+	case azureAuthWorkloadIdentity:
+		tokenFile := os.Getenv("AZURE_FEDERATED_TOKEN_FILE")
+		if *azureTenantID == "" || *azureClientID == "" || tokenFile == "" {
+			return nil, errors.Wrap(errFlag, "workload-identity auth needs --azure-tenant-id, --azure-client-id and AZURE_FEDERATED_TOKEN_FILE")
+		}
+		return azureopenai.NewWorkloadIdentityCredential(*azureTenantID, *azureClientID, tokenFile), nil
+	case azureAuthManagedIdentity:
+		return azureopenai.NewManagedIdentityCredential(*azureClientID), nil
+	}
+	return nil, errors.Wrapf(errFlag, "unknown azure-auth %q", *azureAuth)
+}
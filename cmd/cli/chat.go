@@ -0,0 +1,442 @@
+package cli
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"pradytpk/go-terraform-ai/pkg/session"
+	"pradytpk/go-terraform-ai/pkg/terraform"
+	"pradytpk/go-terraform-ai/pkg/utils"
+	"strings"
+
+	"github.com/chzyer/readline"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	chatSubCommand = "You are a Terraform HCL generator helping an engineer design infrastructure in a conversation. " +
+		"The current configuration and the conversation so far follow. Answer the last user message with a short explanation and, " +
+		"when the configuration changes, the complete updated configuration in a single ```hcl code block, without provider templates.\n"
+
+	chatHelp = `Commands:
+  /plan          save the configuration and run terraform plan
+  /apply         save the configuration and apply it
+  /save          lint and save the configuration
+  /diff          show the changes not saved yet
+  /undo          go back to the previous version of the configuration
+  /files         list the .tf files of the working dir
+  /model [d [m]] show or switch the deployment d and its model m
+  /reset         clear the conversation and the configuration
+  /help          show this help
+  /exit          save the session and quit
+Anything else is sent to the model.`
+)
+
+var errChat = errors.New("invalid chat command")
+
+var (
+	// chatFile is the configuration file a new chat session writes
+	chatFile string
+)
+
+// chat holds the state of an interactive session
+type chat struct {
+	clients oaiClients
+	session *session.Session
+}
+
+// addChat
+//
+//	@return *cobra.Command
+func addChat() *cobra.Command {
+	chatCmd := &cobra.Command{
+		Use:   "chat",
+		Short: "Design infrastructure interactively in a conversation with the model",
+		Args:  cobra.NoArgs,
+		RunE:  chatCommand,
+	}
+	chatCmd.Flags().StringVar(&chatFile, "file", "main.tf", "The configuration file the session writes, an existing file is loaded as the starting point.")
+	return chatCmd
+}
+
+// chatCommand is a function that handles the "chat" command in the CLI
+//
+//	@param _
+//	@param _
+//	@return error
+func chatCommand(_ *cobra.Command, _ []string) error {
+	c, err := newChat()
+	if err != nil {
+		return err
+	}
+	return c.loop()
+}
+
+// newChat starts a new session or resumes the one given by --resume
+//
+//	@return *chat
+//	@return error
+func newChat() (*chat, error) {
+	s, err := startSession(commandChat, chatFile)
+	if err != nil {
+		return nil, err
+	}
+	c := &chat{session: s}
+	if s.File == "" {
+		s.File = chatFile
+	}
+	c.load()
+	clients, err := newOAIClients()
+	if err != nil {
+		return nil, fmt.Errorf("error creating new OAI client: %w", err)
+	}
+	c.clients = clients
+	return c, nil
+}
+
+// load starts a new session from the configuration file when it exists
+//
+//	@receiver c
+func (c *chat) load() {
+	if len(c.session.Versions) > 0 {
+		return
+	}
+	if existing, err := os.ReadFile(c.path()); err == nil {
+		c.session.Push(string(existing))
+		log.Printf("📄 Loaded %s\n", c.session.File)
+	}
+}
+
+// loop reads and handles lines until /exit or end of input, the session is saved after every line
+//
+//	@receiver c
+//	@return error
+func (c *chat) loop() error {
+	log.Printf("Type /help for the commands.\n")
+	for {
+		line, err := c.readLine()
+		if errors.Is(err, readline.ErrInterrupt) {
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("error reading input: %w", err)
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if line == "/exit" || line == "/quit" {
+			break
+		}
+		if err = c.handle(line); err != nil {
+			log.Printf("❌ %s\n", err)
+		}
+		if err = saveSession(); err != nil {
+			return err
+		}
+	}
+	if err := saveSession(); err != nil {
+		return err
+	}
+	log.Printf("💾 Session saved, resume it with `terraform-assistant sessions resume %s`\n", c.session.ID)
+	return nil
+}
+
+// readLine reads a line with history and completion of the commands. A new instance is used
+// for every line so the terminal is free for the prompts of the commands.
+//
+//	@receiver c
+//	@return string
+//	@return error
+func (c *chat) readLine() (string, error) {
+	rl, err := readline.NewEx(&readline.Config{
+		Prompt:          "terraform> ",
+		HistoryFile:     filepath.Join(*workingDir, ".terraform-assistant", "chat_history"),
+		InterruptPrompt: "^C",
+		EOFPrompt:       "/exit",
+		AutoComplete: readline.NewPrefixCompleter(
+			readline.PcItem("/plan"), readline.PcItem("/apply"), readline.PcItem("/save"), readline.PcItem("/diff"),
+			readline.PcItem("/undo"), readline.PcItem("/files"), readline.PcItem("/model"), readline.PcItem("/reset"),
+			readline.PcItem("/help"), readline.PcItem("/exit"),
+		),
+	})
+	if err != nil {
+		return "", err
+	}
+	defer rl.Close()
+	return rl.Readline()
+}
+
+// handle runs a slash command or sends the line to the model. Every line gets its own
+// interrupt context, so an interrupt stops the running command but not the session.
+//
+//	@receiver c
+//	@param line
+//	@return error
+func (c *chat) handle(line string) error {
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	if !strings.HasPrefix(line, "/") {
+		return c.ask(ctx, line)
+	}
+	command, arg, _ := strings.Cut(strings.TrimPrefix(line, "/"), " ")
+	arg = strings.TrimSpace(arg)
+	switch command {
+	case "plan":
+		return c.plan(ctx)
+	case "apply":
+		return c.apply(ctx)
+	case "save":
+		return c.save()
+	case "diff":
+		return c.diff()
+	case "undo":
+		if !c.session.Undo() {
+			return errors.Wrap(errChat, "nothing to undo")
+		}
+		log.Printf("↩️ Back to version %d:\n%s\n", len(c.session.Versions), c.session.Current())
+	case "files":
+		return c.files()
+	case "model":
+		return c.model(arg)
+	case "reset":
+		c.session.Reset()
+		log.Printf("🧹 Conversation cleared, %s is left as it is on disk\n", c.session.File)
+	case "help":
+		fmt.Println(chatHelp)
+	default:
+		return errors.Wrapf(errChat, "unknown command /%s, type /help for the commands", command)
+	}
+	return nil
+}
+
+// ask sends the message with the conversation to the model and keeps the configuration of the answer
+//
+//	@receiver c
+//	@param ctx
+//	@param message
+//	@return error
+func (c *chat) ask(ctx context.Context, message string) error {
+	previous := c.session.Current()
+	c.session.Add(session.RoleUser, message)
+	res, err := completion(ctx, c.clients, c.prompts(), *openAIDeploymentName, chatSubCommand)
+	if err != nil {
+		return fmt.Errorf("error completing chat: %w", err)
+	}
+	c.session.Add(session.RoleAssistant, res)
+
+	text, code := splitCode(res)
+	fmt.Println(text)
+	if code == "" {
+		return nil
+	}
+	code = utils.FormatHCL(code)
+	if err = terraform.CheckTemplate(code); err != nil {
+		return fmt.Errorf("the configuration of the answer is not valid HCL, ask again: %w", err)
+	}
+	c.session.Push(code)
+	if previous == "" {
+		fmt.Printf("\n%s\n", code)
+	} else {
+		fmt.Printf("\n%s\n", utils.Diff(c.session.File, c.session.File+" (new)", previous, code))
+	}
+	return nil
+}
+
+// prompts returns the current configuration followed by as many of the latest messages as fit into
+// the prompt. The configurations in earlier answers are left out, the current one is always sent.
+//
+//	@receiver c
+//	@return []string
+func (c *chat) prompts() []string {
+	current := c.session.Current()
+	if current == "" {
+		current = "(none yet)"
+	}
+	budget := chunkChars() - len(current)
+	var turns []string
+	for i := len(c.session.Messages) - 1; i >= 0; i-- {
+		m := c.session.Messages[i]
+		content := m.Content
+		if m.Role == session.RoleAssistant {
+			content = codeBlockRegex.ReplaceAllString(content, "[configuration]")
+		}
+		turn := fmt.Sprintf("%s: %s", m.Role, content)
+		if budget -= len(turn); budget < 0 && len(turns) > 0 {
+			break
+		}
+		turns = append([]string{turn}, turns...)
+	}
+	return append([]string{fmt.Sprintf("Current configuration (%s):\n%s", c.session.File, current)}, turns...)
+}
+
+// save checks, lints and stores the current configuration into the session file
+//
+//	@receiver c
+//	@return error
+func (c *chat) save() error {
+	current := c.session.Current()
+	if current == "" {
+		return errors.Wrap(errChat, "there is no configuration to save yet")
+	}
+	if err := terraform.CheckTemplate(current); err != nil {
+		return err
+	}
+	if err := lintTemplate(c.session.File, current); err != nil {
+		return err
+	}
+	return storeTemplate(c.path(), current)
+}
+
+// plan saves the configuration and shows the planned changes
+//
+//	@receiver c
+//	@param ctx
+//	@return error
+func (c *chat) plan(ctx context.Context) error {
+	if err := c.save(); err != nil {
+		return err
+	}
+	plan, err := ops.Plan(ctx)
+	if err != nil {
+		recordEvent(session.EventPlan, "plan", err)
+		return fmt.Errorf("error planning: %w", err)
+	}
+	summary := terraform.FormatChanges(terraform.SummarizePlan(plan))
+	recordEvent(session.EventPlan, summary, nil)
+	fmt.Println(summary)
+	return nil
+}
+
+// apply saves and applies the configuration, a failed apply is diagnosed like in run
+//
+//	@receiver c
+//	@param ctx
+//	@return error
+func (c *chat) apply(ctx context.Context) error {
+	if err := c.save(); err != nil {
+		return err
+	}
+	if err := promptVariables(); err != nil {
+		return err
+	}
+	err := applyTerraform(ctx)
+	if err != nil {
+		log.Printf("❌ Apply failed: %s\n", err)
+		err = fixFailedApply(ctx, c.clients, c.path(), err)
+		// keep the fix in the session
+		if fixed, readErr := os.ReadFile(c.path()); readErr == nil {
+			c.session.Push(string(fixed))
+		}
+	}
+	recordEvent(session.EventApply, "apply "+c.session.File, err)
+	if err != nil {
+		return fmt.Errorf("error applying Terraform: %w", err)
+	}
+	log.Printf("✅ Applied\n")
+	return nil
+}
+
+// diff shows the changes of the current configuration that are not saved yet
+//
+//	@receiver c
+//	@return error
+func (c *chat) diff() error {
+	saved, err := os.ReadFile(c.path())
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error reading %s: %w", c.session.File, err)
+	}
+	diff := utils.Diff(c.session.File, c.session.File+" (session)", string(saved), c.rendered())
+	if diff == "" {
+		diff = "No unsaved changes."
+	}
+	fmt.Println(diff)
+	return nil
+}
+
+// files lists the .tf files of the working dir, marking the session file
+//
+//	@receiver c
+//	@return error
+func (c *chat) files() error {
+	files, err := filepath.Glob(filepath.Join(*workingDir, "*.tf"))
+	if err != nil {
+		return fmt.Errorf("error listing tf files: %w", err)
+	}
+	found := false
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %w", file, err)
+		}
+		name, mark := filepath.Base(file), " "
+		if name == c.session.File {
+			found, mark = true, "*"
+			if string(src) != c.rendered() {
+				name += " (unsaved changes)"
+			}
+		}
+		fmt.Printf("%s %-40s %5d lines\n", mark, name, strings.Count(string(src), "\n"))
+	}
+	if !found {
+		fmt.Printf("* %-40s not saved yet\n", c.session.File)
+	}
+	return nil
+}
+
+// model shows the deployment and its model or switches to another deployment, arg is the
+// deployment optionally followed by its model when the deployment is not named after it
+//
+//	@receiver c
+//	@param arg
+//	@return error
+func (c *chat) model(arg string) error {
+	if arg == "" {
+		fmt.Printf("%s (%s)\n", *openAIDeploymentName, modelName(*openAIDeploymentName))
+		return nil
+	}
+	deployment, name, _ := strings.Cut(arg, " ")
+	name = strings.TrimSpace(name)
+	if _, ok := maxTokensMap[cmp.Or(name, deployment)]; !ok {
+		return errors.Wrapf(errChat, "unknown model %q, give the model after the deployment: /model <deployment> <model>", cmp.Or(name, deployment))
+	}
+	previousDeployment, previousModel := *openAIDeploymentName, *model
+	*openAIDeploymentName, *model = deployment, name
+	clients, err := newOAIClients()
+	if err != nil {
+		*openAIDeploymentName, *model = previousDeployment, previousModel
+		return fmt.Errorf("error creating new OAI client: %w", err)
+	}
+	c.clients = clients
+	c.session.Deployment, c.session.Model = deployment, modelName(deployment)
+	log.Printf("🤖 Switched to %s (%s)\n", deployment, modelName(deployment))
+	return nil
+}
+
+// rendered returns the current configuration as it is saved
+//
+//	@receiver c
+//	@return string
+func (c *chat) rendered() string {
+	if c.session.Current() == "" {
+		return ""
+	}
+	return utils.RemoveBlankLinesFromString(utils.FormatHCL(c.session.Current()))
+}
+
+// path returns the path of the session file in the working dir
+//
+//	@receiver c
+//	@return string
+func (c *chat) path() string {
+	return filepath.Join(*workingDir, c.session.File)
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"pradytpk/go-terraform-ai/pkg/session"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestChatSaveKeepsExistingFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		// resumed is the current version of a resumed session
+		resumed string
+		wantErr error
+	}{
+		{
+			name:    "configuration",
+			content: "resource \"aws_s3_bucket\" \"logs\" {\n  bucket = \"logs\"\n}\n",
+		},
+		{
+			name:    "hardcoded credential",
+			content: "resource \"aws_db_instance\" \"db\" {\n  password          = \"Sup3rS3cret!\"\n  storage_encrypted = true\n}\n",
+			wantErr: errSecret,
+		},
+		{
+			name:    "resumed session with a redacted credential",
+			content: "resource \"aws_db_instance\" \"db\" {\n  password          = var.db_password\n  storage_encrypted = true\n}\n",
+			resumed: "resource \"aws_db_instance\" \"db\" {\n  password          = \"[REDACTED]\"\n  storage_encrypted = true\n}\n",
+			wantErr: errSecret,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			oldWorkingDir := *workingDir
+			*workingDir = dir
+			t.Cleanup(func() {
+				*workingDir = oldWorkingDir
+				auditLog, activeChange = nil, nil
+			})
+			path := filepath.Join(dir, "main.tf")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			c := &chat{session: session.New(commandChat, "gpt-4o", "gpt-4o", "main.tf")}
+			if tt.resumed != "" {
+				c.session.Push(tt.resumed)
+			}
+			c.load()
+			err := c.save()
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("save() error = %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("save() error = %v, want %v", err, tt.wantErr)
+			}
+			// saving the session must not change what the chat writes
+			if err = session.Save(filepath.Join(dir, session.DefaultDir), c.session); err != nil {
+				t.Fatal(err)
+			}
+			if err = c.save(); tt.wantErr == nil && err != nil {
+				t.Fatalf("save() after saving the session error = %v", err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.content {
+				t.Errorf("main.tf = %q, want it unchanged %q", got, tt.content)
+			}
+		})
+	}
+}
@@ -1,17 +1,47 @@
 package cli
 
 import (
+	"context"
+	"flag"
 	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"pradytpk/go-terraform-ai/pkg/audit"
+	"pradytpk/go-terraform-ai/pkg/session"
+	"pradytpk/go-terraform-ai/pkg/terraform"
+	"pradytpk/go-terraform-ai/pkg/utils"
+	"strings"
+	"syscall"
 
 	"github.com/manifoldco/promptui"
+	"github.com/pkg/errors"
 )
 
 const (
 	apply     = "Apply"
 	dontApply = "Dont Apply"
 	reprompt  = "Reprompt"
+
+	secretsRedact = "redact"
+	secretsBlock  = "block"
+)
+
+var (
+	// Error for templates blocked by the security lint rules
+	errLint = errors.New("template failed security lint")
+	// Error for prompts and templates containing credentials
+	errSecret = errors.New("credential detected")
 )
 
+// sensitiveVariableHint is shown when a generated template hardcodes a credential
+const sensitiveVariableHint = `declare a sensitive variable and reference it instead, e.g.
+variable "db_password" {
+  type      = string
+  sensitive = true
+}
+and set password = var.db_password, passing the value with TF_VAR_db_password`
+
 // userActionPrompt
 //
 //	@return string
@@ -37,3 +67,186 @@ func userActionPrompt() (string, error) {
 	}
 	return result, nil
 }
+
+// lintTemplate runs the security lint rules against the template, prints the findings
+// and returns an error when a finding reaches the --lint-fail-severity threshold
+//
+//	@param name
+//	@param com
+//	@return error
+func lintTemplate(name string, com string) error {
+	threshold, err := terraform.ParseSeverity(*lintFailSeverity)
+	if err != nil {
+		return fmt.Errorf("error parsing lint severity:%w", err)
+	}
+	findings, err := terraform.Lint(name, []byte(com))
+	if err != nil {
+		recordEvent(session.EventValidation, "lint "+name, err)
+		return fmt.Errorf("error linting template:%w", err)
+	}
+	for _, f := range findings {
+		log.Printf("🚨 %s\n", f)
+	}
+	blocking := terraform.Blocking(findings, threshold)
+	if suppressed := terraform.SuppressedBlocking(findings, threshold); len(suppressed) > 0 && !confirmSuppressions(suppressed) {
+		blocking = append(blocking, suppressed...)
+	}
+	if len(blocking) > 0 {
+		err = errors.Wrapf(errLint, "%d finding(s) at or above %s, fix the template or add a `# terraform-assistant:ignore <rule-id>` comment", len(blocking), threshold)
+	}
+	recordEvent(session.EventValidation, fmt.Sprintf("lint %s: %d finding(s)", name, len(findings)), err)
+	return err
+}
+
+// confirmSuppressions asks whether to honor the ignore comments of a generated template, which the
+// model may have written itself. --require-confirmation=false never honors them.
+//
+//	@param suppressed
+//	@return bool
+func confirmSuppressions(suppressed []terraform.Finding) bool {
+	if !*requireConfirmation {
+		log.Printf("⚠️ Ignoring %d ignore comment(s) of the generated template without confirmation\n", len(suppressed))
+		return false
+	}
+	prompt := promptui.Prompt{
+		Label:     fmt.Sprintf("The generated template suppresses %d blocking finding(s), honor its ignore comments", len(suppressed)),
+		IsConfirm: true,
+	}
+	// a "no" answer is returned as an error by promptui
+	_, err := prompt.Run()
+	return err == nil
+}
+
+// redactPrompts removes credentials from the prompts before they are sent to the model,
+// or returns an error when --secrets=block
+//
+//	@param prompts
+//	@return []string
+//	@return error
+func redactPrompts(prompts []string) ([]string, error) {
+	redacted := make([]string, 0, len(prompts))
+	for _, p := range prompts {
+		clean, matches := utils.RedactSecrets(p)
+		if len(matches) > 0 {
+			if *secretsMode == secretsBlock {
+				return nil, errors.Wrapf(errSecret, "prompt contains %s, remove it before sending", describeSecrets(matches))
+			}
+			log.Printf("🔒 Redacted %s from the prompt\n", describeSecrets(matches))
+		}
+		redacted = append(redacted, clean)
+	}
+	return redacted, nil
+}
+
+// storeTemplate refuses templates with hardcoded credentials, formats the others
+// in the terraform fmt style and stores them, recording them in the change manifest for undo
+//
+//	@param name
+//	@param com
+//	@return error
+func storeTemplate(name string, com string) error {
+	if matches := utils.FindSecrets(com); len(matches) > 0 {
+		err := errors.Wrapf(errSecret, "generated template %s contains %s, %s", name, describeSecrets(matches), sensitiveVariableHint)
+		recordEvent(session.EventFile, "write "+name, err)
+		return err
+	}
+	// saved sessions hold redacted versions, writing one back would replace the real value
+	if strings.Contains(com, utils.Redacted) {
+		err := errors.Wrapf(errSecret, "template %s contains a credential redacted from a saved session, %s", name, sensitiveVariableHint)
+		recordEvent(session.EventFile, "write "+name, err)
+		return err
+	}
+	formatted := utils.FormatHCL(com)
+	if diff := utils.Diff(name, name+" (formatted)", com, formatted); diff != "" {
+		log.Printf("🧹 Formatted %s:\n%s", name, diff)
+	}
+	written := utils.RemoveBlankLinesFromString(formatted)
+	if err := recordChange(name, []byte(written)); err != nil {
+		recordEvent(session.EventFile, "write "+name, err)
+		return err
+	}
+	if err := utils.StoreFile(name, formatted); err != nil {
+		recordEvent(session.EventFile, "write "+name, err)
+		return fmt.Errorf("error store file:%w", err)
+	}
+	sum := audit.SHA256(written)
+	recordEvent(session.EventFile, fmt.Sprintf("write %s (sha256 %s)", name, sum), nil)
+	return auditEvent(audit.Entry{Event: audit.EventFile, File: name, FileSHA256: sum})
+}
+
+// describeSecrets lists the kinds and lines of the matches without their values
+//
+//	@param matches
+//	@return string
+func describeSecrets(matches []utils.SecretMatch) string {
+	parts := make([]string, 0, len(matches))
+	for _, m := range matches {
+		parts = append(parts, m.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// stringSlice is a flag that can be repeated, the default is a comma separated list
+type stringSlice []string
+
+// String returns the values joined by commas
+//
+//	@receiver s
+//	@return string
+func (s *stringSlice) String() string {
+	return strings.Join(*s, ",")
+}
+
+// Set appends a value
+//
+//	@receiver s
+//	@param value
+//	@return error
+func (s *stringSlice) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// Type is used by pflag to describe the flag
+//
+//	@receiver s
+//	@return string
+func (s *stringSlice) Type() string {
+	return "stringArray"
+}
+
+// stringSliceFlag defines a repeatable flag on the command line flag set
+//
+//	@param name
+//	@param value
+//	@param usage
+//	@return *stringSlice
+func stringSliceFlag(name string, value string, usage string) *stringSlice {
+	s := &stringSlice{}
+	if value != "" {
+		*s = strings.Split(value, ",")
+	}
+	flag.Var(s, name, usage)
+	return s
+}
+
+// interruptContext returns a context cancelled on the first interrupt, which lets terraform stop
+// cleanly and release the state lock. A second interrupt terminates the process right away.
+//
+//	@return context.Context
+//	@return context.CancelFunc
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		defer signal.Stop(signals)
+		select {
+		case <-signals:
+			log.Println("🛑 Interrupted, waiting for terraform to stop and release the state lock (interrupt again to force)")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
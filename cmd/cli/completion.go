@@ -30,6 +30,27 @@ var (
 		"gpt-35-turbo-0301":  4096, // for azure
 		"gpt-4-0314":         8192,
 		"gpt-4-32k-0314":     8192,
+		"gpt-3.5-turbo-16k":  16385,
+		"gpt-35-turbo":       4096, // for azure
+		"gpt-35-turbo-16k":   16385,
+		"gpt-4":              8192,
+		"gpt-4-0613":         8192,
+		"gpt-4-32k":          32768,
+		"gpt-4-turbo":        128000,
+		"gpt-4o":             128000,
+		"gpt-4o-mini":        128000,
+		"gpt-4.1":            1047576,
+		"gpt-4.1-mini":       1047576,
+		"gpt-4.1-nano":       1047576,
+	}
+	// Map to hold the maximum tokens of the response of the models whose limit is below their context
+	maxOutputTokensMap = map[string]int{
+		"gpt-4-turbo":  4096,
+		"gpt-4o":       16384,
+		"gpt-4o-mini":  16384,
+		"gpt-4.1":      32768,
+		"gpt-4.1-mini": 32768,
+		"gpt-4.1-nano": 32768,
 	}
 	// Error for invalid max tokens
 	errToken = errors.New("invalid max tokens")
@@ -42,16 +63,35 @@ func newOAIClients() (oaiClients, error) {
 	var (
 		oaiClient   openai.Client
 		azureClient azureopenai.Client
-		err         error
 	)
 	if azureOpenAIEndpoint == nil || *azureOpenAIEndpoint == "" {
-		oaiClient = openai.NewClient(*openAIAPIKey)
+		key, err := apiKey()
+		if err != nil {
+			return oaiClients{}, err
+		}
+		oaiClient = openai.NewClient(key)
 	} else {
 		re := regexp.MustCompile(`^[a-zA-Z0-9]+([_-]?[a-zA-Z0-9]+)*$`)
 		if !re.MatchString(*openAIDeploymentName) {
 			return oaiClients{}, errors.New("azure openai deployment can only include alphanumeric characters,'_,-', and cant end with '_' or '-'")
 		}
-		azureClient, err = azureopenai.NewClient(*azureOpenAIEndpoint, *openAIAPIKey, *openAIDeploymentName)
+		provider, err := azureTokenProvider()
+		if err != nil {
+			return oaiClients{}, err
+		}
+		var (
+			key     string
+			options []azureopenai.ClientOption
+		)
+		if *azureAPIVersion != "" {
+			options = append(options, azureopenai.WithAPIVersion(*azureAPIVersion))
+		}
+		if provider != nil {
+			options = append(options, azureopenai.WithTokenProvider(provider))
+		} else if key, err = apiKey(); err != nil {
+			return oaiClients{}, err
+		}
+		azureClient, err = azureopenai.NewClient(*azureOpenAIEndpoint, key, *openAIDeploymentName, options...)
 		if err != nil {
 			return oaiClients{}, fmt.Errorf("error create azure client:%w", err)
 		}
@@ -74,7 +114,12 @@ func newOAIClients() (oaiClients, error) {
 //	@return error
 func completion(ctx context.Context, client oaiClients, prompts []string, deploymentName string, subcommand string) (string, error) {
 	temp := float32(*temperature)
-	maxTokens, err := calculateMaxTokens(prompts, deploymentName)
+	prompts, err := redactPrompts(prompts)
+	if err != nil {
+		return "", err
+	}
+	model := modelName(deploymentName)
+	maxTokens, err := calculateMaxTokens(prompts, model)
 	if err != nil {
 		return "", fmt.Errorf("error prompt string builder:%w", err)
 	}
@@ -84,51 +129,63 @@ func completion(ctx context.Context, client oaiClients, prompts []string, deploy
 		return "", fmt.Errorf("error prompt string builder:%w", err)
 	}
 	for _, p := range prompts {
-		_, err = fmt.Fprint(&prompt, "%s\n", p)
+		_, err = fmt.Fprintf(&prompt, "%s\n", p)
 		if err != nil {
 			return "", fmt.Errorf("error range prompt:%w", err)
 		}
 	}
-	if azureOpenAIEndpoint == nil || *azureOpenAIEndpoint == "" {
-		if isGptTurbo(deploymentName) || isGpt4(deploymentName) {
-			res, err := client.openaiGptChatCompletion(ctx, prompt, maxTokens, temp)
+	var res string
+	switch {
+	case *agentMode:
+		if !isChatModel(model) {
+			return "", errors.Wrapf(errFlag, "--agent needs a chat model, %s is not one", model)
+		}
+		res, err = client.agentCompletion(ctx, prompt, maxTokens, temp)
+		if err != nil {
+			return "", fmt.Errorf("error agent completion:%w", err)
+		}
+	case azureOpenAIEndpoint == nil || *azureOpenAIEndpoint == "":
+		if isChatModel(model) {
+			res, err = client.openaiGptChatCompletion(ctx, prompt, maxTokens, temp)
 			if err != nil {
 				return "", fmt.Errorf("error openai gptchart Completion:%w", err)
 			}
-			return res, nil
+			break
 		}
-		res, err := client.openaiGptCompletion(ctx, prompt, maxTokens, temp)
+		res, err = client.openaiGptCompletion(ctx, prompt, maxTokens, temp)
 		if err != nil {
 			return "", fmt.Errorf("error open ai gpt Completion:%w", err)
 		}
-		return res, nil
-	}
-	if isGptTurbo35(deploymentName) || isGpt4(deploymentName) {
-		resp, err := client.azureGptChatCompletion(ctx, prompt, maxTokens, temp)
+	case isChatModel(model):
+		res, err = client.azureGptChatCompletion(ctx, prompt, maxTokens, temp)
 		if err != nil {
 			return "", fmt.Errorf("error azure GptChat Completion:%w", err)
 		}
-		return resp, nil
-	}
-	resp, err := client.azureGptCompletion(ctx, prompt, maxTokens, temp)
-	if err != nil {
-		return "", fmt.Errorf("error azure Gpt completion: %w", err)
+	default:
+		res, err = client.azureGptCompletion(ctx, prompt, maxTokens, temp)
+		if err != nil {
+			return "", fmt.Errorf("error azure Gpt completion: %w", err)
+		}
 	}
 
-	return resp, nil
+	// Every prompt and response is recorded before the response is used.
+	if err = auditPrompt(prompt.String(), res); err != nil {
+		return "", err
+	}
+	return res, nil
 }
 
-// calculateMaxTokens is a function that calculates the maximum tokena allowed for a given deployment name
+// calculateMaxTokens is a function that calculates the maximum tokens of the response for a given model
 //
 //	@param prompts
-//	@param deploymentName
+//	@param model
 //	@return *int
 //	@return error
-func calculateMaxTokens(prompts []string, deploymentName string) (*int, error) {
-	// Get the maximum tokens allowed for the deploymentName from the maxTokensMap
-	maxTokensFinal, ok := maxTokensMap[deploymentName]
+func calculateMaxTokens(prompts []string, model string) (*int, error) {
+	// Get the maximum tokens allowed for the model from the maxTokensMap
+	maxTokensFinal, ok := maxTokensMap[model]
 	if !ok {
-		return nil, errors.Wrapf(errToken, "deploymentName %q not found in max tokens map", deploymentName)
+		return nil, errors.Wrapf(errToken, "model %q not found in max tokens map, set --model to the model of the deployment", model)
 	}
 
 	// If a custom maxTokens value is provided, override the value from the map
@@ -155,32 +212,35 @@ func calculateMaxTokens(prompts []string, deploymentName string) (*int, error) {
 		totalTokens += len(tokens)
 	}
 
-	// Calculate the remaining tokens by subtracting the total tokens from the maximum tokens allowed
+	// Calculate the remaining tokens by subtracting the total tokens from the maximum tokens allowed,
+	// without going over the limit of the response of the model
 	remainingTokens := maxTokensFinal - totalTokens
+	if limit, ok := maxOutputTokensMap[model]; ok && *maxTokens <= 0 && remainingTokens > limit {
+		remainingTokens = limit
+	}
 
 	return &remainingTokens, nil
 }
 
-// isGptTurbo35 is a function that checks deployment names.
+// modelName returns the model behind the deployment, --model when it is set
 //
 //	@param deploymentName
-//	@return bool
-func isGptTurbo35(deploymentName string) bool {
-	return deploymentName == "gpt-35-turbo-0301" || deploymentName == "gpt-35-turbo"
-}
-
-// isGpt4 is a function that checks deployment names.
-//
-//	@param deploymentName
-//	@return bool
-func isGpt4(deploymentName string) bool {
-	return deploymentName == "gpt-4-0314" || deploymentName == "gpt-4-32k-0314"
+//	@return string
+func modelName(deploymentName string) string {
+	if *model != "" {
+		return *model
+	}
+	return deploymentName
 }
 
-// isGptTurbo is a function that checks deployment names.
+// isChatModel reports whether the model is served by the chat completions API, which is the case
+// of the gpt-3.5-turbo (gpt-35-turbo on azure) and gpt-4 families except the instruct models
 //
-//	@param deploymentName
+//	@param model
 //	@return bool
-func isGptTurbo(deploymentName string) bool {
-	return deploymentName == "gpt-3.5-turbo-0301" || deploymentName == "gpt-3.5-turbo"
+func isChatModel(model string) bool {
+	if strings.HasSuffix(model, "-instruct") {
+		return false
+	}
+	return strings.HasPrefix(model, "gpt-3.5-turbo") || strings.HasPrefix(model, "gpt-35-turbo") || strings.HasPrefix(model, "gpt-4")
 }
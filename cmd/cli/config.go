@@ -0,0 +1,363 @@
+package cli
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"pradytpk/go-terraform-ai/pkg/config"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Sources of the values shown by config list besides the config layers
+const (
+	sourceFlag    = "flag"
+	sourceEnv     = "env"
+	sourceDefault = "default"
+)
+
+var (
+	// configValues and configSources are the merged settings of the config files and the active profile
+	configValues  = map[string]string{}
+	configSources = map[string]string{}
+	// configGlobal writes to the user config file instead of the project one
+	configGlobal bool
+
+	// flagEnv holds the environment variables whose name is not the flag name in upper snake case
+	flagEnv = map[string]string{
+		"open-ai-key": "OPENAI_API_KEY",
+		"model":       "OPENAI_MODEL",
+		"policy":      "POLICY_PATH",
+		"secrets":     "SECRETS_MODE",
+		"profile":     "TERRAFORM_ASSISTANT_PROFILE",
+		"var":         "",
+		"resume":      "",
+	}
+	// notConfigurable are the flags the config files cannot set, credentials do not belong in them
+	notConfigurable = map[string]bool{"open-ai-key": true, "profile": true, "resume": true, "help": true, "version": true}
+	// userOnly are the flags the project file cannot set, as it comes with a repository that may not be trusted
+	userOnly = map[string]bool{
+		// a credential helper command would run whatever the repository chose
+		"api-key-command": true, "api-key-file": true,
+		// the key and the Entra tokens would be sent to a host the repository chose
+		"azure-openai-endpoint": true, "azure-auth": true, "azure-tenant-id": true, "azure-client-id": true,
+		// the terraform binary
+		"exec-dir": true,
+		// the safety gates
+		"require-confirmation": true, "auto-fix": true, "lint-fail-severity": true, "secrets": true,
+		"policy": true, "audit-log": true, "audit-prompt": true,
+		"destroy": true, "force": true, "force-copy": true, "migrate-state": true, "reconfigure": true, "state-rm": true,
+	}
+)
+
+// addConfig
+//
+//	@return *cobra.Command
+func addConfig() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Show and change the settings of the user and project config files and their profiles",
+		// the config commands need no terraform, and set must work before its profile exists
+		PersistentPreRunE: configPreRun,
+	}
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the settings in effect and where each comes from",
+		Args:  cobra.NoArgs,
+		RunE:  configListCommand,
+	}
+	getCmd := &cobra.Command{
+		Use:   "get <key>",
+		Short: "Print the value in effect of a setting",
+		Args:  cobra.ExactArgs(1),
+		RunE:  configGetCommand,
+	}
+	setCmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a setting in the project config file, or in the profile given with --profile",
+		Args:  cobra.ExactArgs(2),
+		RunE:  configSetCommand,
+	}
+	setCmd.Flags().BoolVar(&configGlobal, "global", false, "Write to the user config file instead of the project one.")
+	profilesCmd := &cobra.Command{
+		Use:   "profiles",
+		Short: "List the profiles of the config files",
+		Args:  cobra.NoArgs,
+		RunE:  configProfilesCommand,
+	}
+	configCmd.AddCommand(listCmd, getCmd, setCmd, profilesCmd)
+	return configCmd
+}
+
+// configPreRun applies the config files, except for config set
+//
+//	@param cmd
+//	@param _
+//	@return error
+func configPreRun(cmd *cobra.Command, _ []string) error {
+	if cmd.Name() == "set" {
+		return nil
+	}
+	return applyConfig(cmd)
+}
+
+// configListCommand is a function that handles the "config list" command in the CLI
+//
+//	@param cmd
+//	@param _
+//	@return error
+func configListCommand(cmd *cobra.Command, _ []string) error {
+	keys := []string{}
+	cmd.Root().PersistentFlags().VisitAll(func(f *pflag.Flag) {
+		if !notConfigurable[f.Name] {
+			keys = append(keys, f.Name)
+		}
+	})
+	for key := range configValues {
+		if cmd.Flags().Lookup(key) == nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+	for _, key := range keys {
+		value, source := configValue(cmd, key)
+		fmt.Fprintf(w, "%s\t%s\t%s\n", key, value, source)
+	}
+	return w.Flush()
+}
+
+// configGetCommand is a function that handles the "config get" command in the CLI
+//
+//	@param cmd
+//	@param args
+//	@return error
+func configGetCommand(cmd *cobra.Command, args []string) error {
+	value, source := configValue(cmd, args[0])
+	if notConfigurable[args[0]] || source == "" {
+		return errors.Wrapf(errFlag, "unknown setting %q, see config list", args[0])
+	}
+	fmt.Println(value)
+	return nil
+}
+
+// configSetCommand is a function that handles the "config set" command in the CLI
+//
+//	@param cmd
+//	@param args
+//	@return error
+func configSetCommand(cmd *cobra.Command, args []string) error {
+	key, value := args[0], args[1]
+	user, project, err := configFiles()
+	if err != nil {
+		return err
+	}
+	file := project
+	if configGlobal {
+		file = user
+	}
+	target := ""
+	if cmd.Flags().Changed("profile") {
+		target = *profile
+	}
+
+	if key == "profile" {
+		// the default profile
+		file.Profile = value
+	} else {
+		f := findFlag(cmd.Root(), key)
+		if f == nil || notConfigurable[key] {
+			return errors.Wrapf(errFlag, "unknown setting %q, see config list", key)
+		}
+		if userOnly[key] && !configGlobal {
+			return errors.Wrapf(errFlag, "%s can only be set in the user config file, use --global", key)
+		}
+		if err = setFlag(f, value); err != nil {
+			return errors.Wrapf(errFlag, "invalid value %q for %s: %s", value, key, err)
+		}
+		file.Set(target, key, value)
+	}
+	if err = file.Save(); err != nil {
+		return err
+	}
+	if target != "" {
+		log.Printf("✅ Set %s=%s in profile %s of %s\n", key, value, target, file.Path())
+	} else {
+		log.Printf("✅ Set %s=%s in %s\n", key, value, file.Path())
+	}
+	return nil
+}
+
+// configProfilesCommand is a function that handles the "config profiles" command in the CLI
+//
+//	@param _
+//	@param _
+//	@return error
+func configProfilesCommand(_ *cobra.Command, _ []string) error {
+	user, project, err := configFiles()
+	if err != nil {
+		return err
+	}
+	names := config.Profiles(user, project)
+	if len(names) == 0 {
+		fmt.Printf("No profiles, add one with: terraform-assistant --profile <name> config set <key> <value>\n")
+		return nil
+	}
+	active := config.ActiveProfile(*profile, user, project)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tNAME\tDEFINED IN\tSETTINGS")
+	for _, name := range names {
+		marker := ""
+		if name == active {
+			marker = "*"
+		}
+		var definedIn, settings []string
+		merged := map[string]string{}
+		for _, f := range []*config.File{user, project} {
+			if p, ok := f.Profiles[name]; ok {
+				definedIn = append(definedIn, f.Path())
+				for k, v := range p {
+					merged[k] = v
+				}
+			}
+		}
+		for k, v := range merged {
+			settings = append(settings, k+"="+v)
+		}
+		sort.Strings(settings)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", marker, name, strings.Join(definedIn, ", "), strings.Join(settings, " "))
+	}
+	return w.Flush()
+}
+
+// applyConfig sets the flags of the command that were given neither on the command line nor in
+// the environment from the config files: user, then project, then the active profile
+//
+//	@param cmd
+//	@return error
+func applyConfig(cmd *cobra.Command) error {
+	user, project, err := configFiles()
+	if err != nil {
+		return err
+	}
+	layers, err := config.Resolve(user, project, config.ActiveProfile(*profile, user, project))
+	if err != nil {
+		return err
+	}
+	for _, layer := range layers {
+		for key, value := range layer.Settings {
+			if notConfigurable[key] {
+				return errors.Wrapf(errFlag, "%s cannot be set in a config file (%s)", key, layer.Source)
+			}
+			if layer.Project && userOnly[key] {
+				return errors.Wrapf(errFlag, "%s cannot be set in the project config file (%s), set it in the user config file, with a flag or in the environment", key, layer.Source)
+			}
+			configValues[key], configSources[key] = value, layer.Source
+		}
+	}
+	for key, value := range configValues {
+		f := cmd.Flags().Lookup(key)
+		if f == nil || f.Changed || envSet(key) {
+			continue
+		}
+		if err = setFlag(f, value); err != nil {
+			return errors.Wrapf(errFlag, "invalid value %q for %s in the %s config: %s", value, key, configSources[key], err)
+		}
+	}
+	return nil
+}
+
+// configFiles loads the user config file and the project config file of the working dir
+//
+//	@return *config.File
+//	@return *config.File
+//	@return error
+func configFiles() (*config.File, *config.File, error) {
+	userPath, err := config.UserPath()
+	if err != nil {
+		return nil, nil, err
+	}
+	user, err := config.Load(userPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	project, err := config.Load(filepath.Join(*workingDir, config.ProjectFile))
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, project, nil
+}
+
+// configValue returns the value of a setting in effect for the command and where it comes from
+//
+//	@param cmd
+//	@param key
+//	@return string
+//	@return string
+func configValue(cmd *cobra.Command, key string) (string, string) {
+	f := cmd.Flags().Lookup(key)
+	switch {
+	case f == nil:
+		return configValues[key], configSources[key]
+	case f.Changed:
+		return f.Value.String(), sourceFlag
+	case envSet(key):
+		return f.Value.String(), sourceEnv
+	case configSources[key] != "":
+		return f.Value.String(), configSources[key]
+	}
+	return f.Value.String(), sourceDefault
+}
+
+// envSet reports whether the environment variable of the flag is set
+//
+//	@param key
+//	@return bool
+func envSet(key string) bool {
+	name, ok := flagEnv[key]
+	if !ok {
+		name = strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+	}
+	return name != "" && os.Getenv(name) != ""
+}
+
+// findFlag looks up a flag of the command or of any of its subcommands
+//
+//	@param cmd
+//	@param key
+//	@return *pflag.Flag
+func findFlag(cmd *cobra.Command, key string) *pflag.Flag {
+	if f := cmd.Flags().Lookup(key); f != nil {
+		return f
+	}
+	for _, sub := range cmd.Commands() {
+		if f := findFlag(sub, key); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// setFlag sets the flag to a config value, repeatable flags take a comma separated list
+//
+//	@param f
+//	@param value
+//	@return error
+func setFlag(f *pflag.Flag, value string) error {
+	if !strings.HasSuffix(f.Value.Type(), "Array") && !strings.HasSuffix(f.Value.Type(), "Slice") {
+		return f.Value.Set(value)
+	}
+	for _, v := range strings.Split(value, ",") {
+		if err := f.Value.Set(strings.TrimSpace(v)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
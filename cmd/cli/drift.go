@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"pradytpk/go-terraform-ai/pkg/terraform"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	driftSubCommand = "You are a Terraform expert. The following resources were changed outside of Terraform (drift found by a refresh-only plan). " +
+		"Summarize in plain language what changed and its likely cause, and for each resource suggest a remediation: " +
+		"update the Terraform code to match the change when it looks intended, or apply the configuration to revert it when it does not. Be concise.\n"
+
+	// exitDrift is the exit code when drift is found, like terraform plan -detailed-exitcode
+	exitDrift = 2
+)
+
+// errDrift is returned when drift is found so the command exits with exitDrift
+var errDrift = errors.New("drift detected")
+
+var (
+	// driftSummary asks the model for a summary and remediation of the drift
+	driftSummary bool
+	// driftReportFile is where the drift report is written, empty prints it only
+	driftReportFile string
+)
+
+// addDrift
+//
+//	@return *cobra.Command
+func addDrift() *cobra.Command {
+	driftCmd := &cobra.Command{
+		Use:   "drift",
+		Short: "Detect changes made outside of terraform, exits with 0 when there is no drift, 2 on drift and 1 on errors",
+		Args:  cobra.NoArgs,
+		RunE:  driftCommand,
+	}
+	driftCmd.Flags().BoolVar(&driftSummary, "summary", false, "Ask the model for a summary of the drift and the suggested remediation.")
+	driftCmd.Flags().StringVarP(&driftReportFile, "out", "o", "", "Also write the report as markdown to this file.")
+	return driftCmd
+}
+
+// driftCommand is a function that handles the "drift" command in the CLI
+//
+//	@param _
+//	@param _
+//	@return error
+func driftCommand(_ *cobra.Command, _ []string) error {
+	return drift()
+}
+
+// drift runs a refresh-only plan and reports the resources changed outside of terraform
+//
+//	@return error
+func drift() error {
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	plan, err := ops.Drift(ctx)
+	if err != nil {
+		return fmt.Errorf("error detecting drift: %w", err)
+	}
+	drifts := terraform.Drift(plan)
+	report := terraform.FormatDrift(drifts)
+	fmt.Println(report)
+
+	summary := ""
+	if driftSummary && len(drifts) > 0 {
+		oaiClients, err := newOAIClients()
+		if err != nil {
+			return fmt.Errorf("error creating new OAI client: %w", err)
+		}
+		res, err := completion(ctx, oaiClients, []string{report}, *openAIDeploymentName, driftSubCommand)
+		if err != nil {
+			return fmt.Errorf("error completing drift command: %w", err)
+		}
+		summary = strings.TrimSpace(res)
+		fmt.Printf("\n%s\n", summary)
+	}
+
+	if driftReportFile != "" {
+		md := fmt.Sprintf("# Drift report\n\n```\n%s\n```\n", report)
+		if summary != "" {
+			md += fmt.Sprintf("\n## Summary\n\n%s\n", summary)
+		}
+		if err = os.WriteFile(driftReportFile, []byte(md), 0o600); err != nil {
+			return fmt.Errorf("error writing report: %w", err)
+		}
+		log.Printf("📝 Report written to %s\n", driftReportFile)
+	}
+
+	if len(drifts) > 0 {
+		return errors.Wrapf(errDrift, "%d resources changed outside of terraform", len(drifts))
+	}
+	return nil
+}
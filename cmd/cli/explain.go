@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"fmt"
+	"log"
+	"pradytpk/go-terraform-ai/pkg/terraform"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	explainSubCommand = "You are a Terraform expert explaining existing Terraform code to an engineer new to the project. " +
+		"Explain in plain language what infrastructure the code creates, the dependencies between the resources and the module inputs (variables) and outputs. " +
+		"Use short sections with headings: Overview, Resources, Dependencies, Inputs, Outputs. The inventory of blocks and the code follow.\n"
+	explainChunkSubCommand = "You are a Terraform expert. The following is part %d of %d of a Terraform module. " +
+		"Describe what the resources in this part create, which other resources, variables or modules they reference, and the variables and outputs declared. Be concise.\n"
+	explainSummarySubCommand = "You are a Terraform expert explaining existing Terraform code to an engineer new to the project. " +
+		"The following are notes on each part of a module and its inventory of blocks. Combine them into one explanation of what infrastructure the module creates, " +
+		"the dependencies between the resources and its inputs (variables) and outputs. Use short sections with headings: Overview, Resources, Dependencies, Inputs, Outputs.\n"
+)
+
+// addExplain
+//
+//	@return *cobra.Command
+func addExplain() *cobra.Command {
+	explainCmd := &cobra.Command{
+		Use:   "explain <path>",
+		Short: "Explain what existing Terraform code (a .tf file or a module directory) creates",
+		Args:  cobra.ExactArgs(1),
+		RunE:  explainCommand,
+		// explain only reads the files, the agent tools create the terraform operations when they need them
+		PersistentPreRunE: preRun,
+	}
+	return explainCmd
+}
+
+// explainCommand is a function that handles the "explain" command in the CLI
+//
+//	@param _
+//	@param args
+//	@return error
+func explainCommand(_ *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return errors.Wrap(errLength, "path must be provided")
+	}
+	return explain(args[0])
+}
+
+// explain reads the configuration at path, sends it to the model in chunks of blocks
+// and prints the explanation
+//
+//	@param path
+//	@return error
+func explain(path string) error {
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	blocks, err := terraform.ReadBlocks(path)
+	if err != nil {
+		return fmt.Errorf("error reading configuration: %w", err)
+	}
+	oaiClients, err := newOAIClients()
+	if err != nil {
+		return fmt.Errorf("error creating new OAI client: %w", err)
+	}
+
+	inventory := "Inventory:\n" + terraform.Inventory(blocks)
+	chunks := terraform.Chunk(blocks, chunkChars())
+	if len(chunks) == 1 {
+		res, err := completion(ctx, oaiClients, []string{inventory, chunks[0]}, *openAIDeploymentName, explainSubCommand)
+		if err != nil {
+			return fmt.Errorf("error completing explain command: %w", err)
+		}
+		fmt.Println(strings.TrimSpace(res))
+		return nil
+	}
+
+	notes := make([]string, 0, len(chunks))
+	for i, chunk := range chunks {
+		log.Printf("📖 Reading part %d of %d\n", i+1, len(chunks))
+		res, err := completion(ctx, oaiClients, []string{chunk}, *openAIDeploymentName, fmt.Sprintf(explainChunkSubCommand, i+1, len(chunks)))
+		if err != nil {
+			return fmt.Errorf("error completing explain command for part %d: %w", i+1, err)
+		}
+		notes = append(notes, fmt.Sprintf("Notes on part %d:\n%s", i+1, strings.TrimSpace(res)))
+	}
+	res, err := completion(ctx, oaiClients, append([]string{inventory}, notes...), *openAIDeploymentName, explainSummarySubCommand)
+	if err != nil {
+		return fmt.Errorf("error completing explain summary: %w", err)
+	}
+	fmt.Println(strings.TrimSpace(res))
+	return nil
+}
+
+// chunkChars returns how many characters of code fit in a prompt, using half of the
+// model context for the code and assuming at least 3 characters per token
+//
+//	@return int
+func chunkChars() int {
+	tokens, ok := maxTokensMap[modelName(*openAIDeploymentName)]
+	if *maxTokens > 0 {
+		tokens = *maxTokens
+	} else if !ok {
+		tokens = 4096
+	}
+	return tokens / 2 * 3
+}
@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"pradytpk/go-terraform-ai/pkg/terraform"
+	"pradytpk/go-terraform-ai/pkg/utils"
+	"regexp"
+	"strings"
+)
+
+const (
+	fixSubCommand = "You are a Terraform expert. Applying the following Terraform configuration failed. " +
+		"Common causes are quota limits, naming constraints of the provider and missing IAM permissions. " +
+		"First give a short diagnosis of the cause in plain language. Then, if the configuration can be fixed, " +
+		"give the complete corrected content of the file %s in a single ```hcl code block. " +
+		"If the fix is outside of the configuration (e.g. requesting a quota increase or granting permissions), say so and give no code block.\n"
+
+	// maxFixAttempts limits how many times a fix is applied and retried
+	maxFixAttempts = 3
+)
+
+// codeBlockRegex matches the first fenced code block of a model response
+var codeBlockRegex = regexp.MustCompile("(?s)```[a-zA-Z]*\\n(.*?)```")
+
+// fixFailedApply asks the model to diagnose a failed apply, shows the proposed fix as a diff and
+// lets the user apply it and retry, reprompt with more instructions or give up. Interrupts and
+// timeouts are not configuration errors and are returned as they are. Without confirmation, fixes
+// are only applied with --auto-fix.
+//
+//	@param ctx
+//	@param clients
+//	@param name
+//	@param applyErr
+//	@return error
+func fixFailedApply(ctx context.Context, clients oaiClients, name string, applyErr error) error {
+	var instructions []string
+	for attempt := 1; attempt <= maxFixAttempts; attempt++ {
+		if ctx.Err() != nil || terraform.Interrupted(applyErr) {
+			return applyErr
+		}
+		current, err := os.ReadFile(name)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %w", name, err)
+		}
+		prompts := append(fixContext(name, string(current), applyErr), instructions...)
+		res, err := completion(ctx, clients, prompts, *openAIDeploymentName, fmt.Sprintf(fixSubCommand, name))
+		if err != nil {
+			return fmt.Errorf("error completing fix command: %w", err)
+		}
+		diagnosis, fix := splitCode(res)
+		log.Printf("\n🩺 Diagnosis:\n%s\n", diagnosis)
+		if fix == "" {
+			return applyErr
+		}
+		fix = utils.FormatHCL(fix)
+		log.Printf("🔧 Proposed fix:\n%s", utils.Diff(name, name+" (fixed)", string(current), fix))
+		if !*requireConfirmation && !*autoFix {
+			log.Printf("The fix was not applied, review it with confirmation or apply it unattended with --auto-fix\n")
+			return applyErr
+		}
+
+		action, err := userActionPrompt()
+		if err != nil {
+			return err
+		}
+		switch action {
+		case dontApply:
+			return applyErr
+		case apply:
+		default:
+			// the user typed new instructions, ask again
+			instructions = append(instructions, action)
+			attempt--
+			continue
+		}
+
+		if err = terraform.CheckTemplate(fix); err != nil {
+			return fmt.Errorf("error checking fix: %w", err)
+		}
+		if err = lintTemplate(name, fix); err != nil {
+			return err
+		}
+		if err = storeTemplate(name, fix); err != nil {
+			return err
+		}
+		if applyErr = applyTerraform(ctx); applyErr == nil {
+			return nil
+		}
+		log.Printf("❌ Apply failed again (attempt %d of %d): %s\n", attempt, maxFixAttempts, applyErr)
+	}
+	return applyErr
+}
+
+// fixContext builds the prompts holding the failed file, the terraform error output and the
+// other files the error diagnostics point to
+//
+//	@param name
+//	@param current
+//	@param applyErr
+//	@return []string
+func fixContext(name string, current string, applyErr error) []string {
+	failure := ops.LastFailure()
+	output := failure.Output
+	if strings.TrimSpace(output) == "" {
+		output = applyErr.Error()
+	}
+	prompts := []string{
+		fmt.Sprintf("Terraform error output:\n%s", output),
+		fmt.Sprintf("File %s:\n%s", name, current),
+	}
+	for _, file := range failure.Files {
+		if filepath.Base(file) == filepath.Base(name) {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(*workingDir, file))
+		if err != nil {
+			continue
+		}
+		prompts = append(prompts, fmt.Sprintf("File %s (for reference, do not change it):\n%s", file, content))
+	}
+	return prompts
+}
+
+// splitCode splits a model response into its text and the content of its first code block
+//
+//	@param res
+//	@return string
+//	@return string
+func splitCode(res string) (string, string) {
+	loc := codeBlockRegex.FindStringSubmatchIndex(res)
+	if loc == nil {
+		return strings.TrimSpace(res), ""
+	}
+	text := strings.TrimSpace(res[:loc[0]] + res[loc[1]:])
+	return text, res[loc[2]:loc[3]]
+}
@@ -0,0 +1,237 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"pradytpk/go-terraform-ai/pkg/terraform"
+	"pradytpk/go-terraform-ai/pkg/utils"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	importSubCommand = "You are a Terraform HCL generator for Terraform 1.5+ import blocks. " +
+		"For the existing cloud resources described below (a description or a list of resource IDs or ARNs), generate only `import` blocks, " +
+		"each with `to` set to a resource address of the correct resource type and a descriptive name, and `id` set to the import ID that resource type expects. " +
+		"Do not generate resource blocks, providers or any other block.\n"
+	importCleanupSubCommand = "You are a Terraform expert cleaning up configuration generated by terraform plan -generate-config-out for imported resources. " +
+		"Remove attributes that are computed, read only, null or set to the provider default, replace hardcoded IDs and ARNs of other resources in the configuration " +
+		"with references to them, and keep every value that is needed for the plan to show no changes. Keep the resource addresses unchanged. " +
+		"Answer with the complete cleaned configuration in a single ```hcl code block.\n"
+)
+
+var (
+	// importsFile is the file the import blocks are written to
+	importsFile string
+	// generatedFile is the file terraform writes the generated configuration to
+	generatedFile string
+	// importCleanup asks the model to clean up the generated configuration
+	importCleanup bool
+)
+
+// addImport
+//
+//	@return *cobra.Command
+func addImport() *cobra.Command {
+	importCmd := &cobra.Command{
+		Use:   "import <description or resource IDs>",
+		Short: "Import existing resources with import blocks and generated configuration",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  importCommand,
+	}
+	importCmd.Flags().StringVar(&importsFile, "imports-file", "imports.tf", "The file the import blocks are written to.")
+	importCmd.Flags().StringVar(&generatedFile, "generated-file", "generated.tf", "The file the generated configuration is written to, it must not exist.")
+	importCmd.Flags().BoolVar(&importCleanup, "cleanup", true, "Ask the model to clean up the generated configuration.")
+	return importCmd
+}
+
+// importCommand is a function that handles the "import" command in the CLI
+//
+//	@param _
+//	@param args
+//	@return error
+func importCommand(_ *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return errors.Wrap(errLength, "description or resource IDs must be provided")
+	}
+	return importResources(args)
+}
+
+// importResources has the model write the import blocks, plans them with configuration
+// generation, optionally cleans up the generated configuration and applies the import
+//
+//	@param args
+//	@return error
+func importResources(args []string) error {
+	importsPath := filepath.Join(*workingDir, importsFile)
+	generatedPath := filepath.Join(*workingDir, generatedFile)
+	if _, err := os.Stat(generatedPath); err == nil {
+		return errors.Wrapf(errFlag, "%s already exists, remove it or choose another --generated-file", generatedFile)
+	}
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	oaiClients, err := newOAIClients()
+	if err != nil {
+		return fmt.Errorf("error creating new OAI client: %w", err)
+	}
+
+	var action, blocks string
+	var imports []terraform.Import
+	for action != apply {
+		args = append(args, action)
+		res, err := completion(ctx, oaiClients, args, *openAIDeploymentName, importSubCommand)
+		if err != nil {
+			return fmt.Errorf("error completing import command: %w", err)
+		}
+		if _, code := splitCode(res); code != "" {
+			res = code
+		}
+		blocks = utils.FormatHCL(res)
+		imports, err = terraform.CheckImports(blocks)
+		if err != nil {
+			return err
+		}
+		log.Printf("\n📥 Resources to import:\n")
+		for _, imp := range imports {
+			log.Printf("  %s <- %s\n", imp.To, imp.ID)
+		}
+		action, err = userActionPrompt()
+		if err != nil {
+			return err
+		}
+		if action == dontApply {
+			return nil
+		}
+	}
+	if err = storeTemplate(importsPath, blocks); err != nil {
+		return err
+	}
+	// the generated resource blocks declare existing infrastructure, undo must not destroy it
+	addresses := make([]string, 0, len(imports))
+	for _, imp := range imports {
+		addresses = append(addresses, imp.To)
+	}
+	if err = recordImports(addresses); err != nil {
+		return err
+	}
+
+	log.Printf("🛠️ Generating configuration into %s\n", generatedFile)
+	// terraform writes the file itself, it is recorded as created before and with its contents after
+	if err = recordChange(generatedPath, nil); err != nil {
+		return err
+	}
+	plan, planErr := ops.GenerateConfig(ctx, generatedPath)
+	if generated, err := os.ReadFile(generatedPath); err == nil {
+		if err = recordChange(generatedPath, generated); err != nil {
+			return err
+		}
+	}
+	if planErr != nil {
+		// terraform still writes the generated configuration when it does not plan cleanly,
+		// the cleanup can fix it
+		if _, statErr := os.Stat(generatedPath); statErr != nil || !importCleanup {
+			return fmt.Errorf("error generating configuration: %w", planErr)
+		}
+		log.Printf("⚠️ The generated configuration does not plan cleanly: %s\n", planErr)
+	}
+
+	if importCleanup {
+		cleaned, err := cleanupGenerated(ctx, oaiClients, generatedPath, blocks, planErr)
+		if err != nil {
+			return err
+		}
+		if cleaned {
+			if plan, err = ops.Plan(ctx); err != nil {
+				return fmt.Errorf("error planning the import: %w", err)
+			}
+		}
+	}
+	return applyImport(ctx, plan)
+}
+
+// cleanupGenerated asks the model to clean up the generated configuration and stores it once
+// the user approves the diff, it reports whether the configuration was changed
+//
+//	@param ctx
+//	@param clients
+//	@param path
+//	@param blocks
+//	@param planErr
+//	@return bool
+//	@return error
+func cleanupGenerated(ctx context.Context, clients oaiClients, path string, blocks string, planErr error) (bool, error) {
+	generated, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	prompts := []string{"Import blocks:\n" + blocks, "Generated configuration:\n" + string(generated)}
+	if planErr != nil {
+		prompts = append(prompts, "The generated configuration does not plan cleanly, fix these errors:\n"+ops.LastFailure().Output)
+	}
+	for {
+		res, err := completion(ctx, clients, prompts, *openAIDeploymentName, importCleanupSubCommand)
+		if err != nil {
+			return false, fmt.Errorf("error completing import cleanup: %w", err)
+		}
+		_, code := splitCode(res)
+		if code == "" {
+			code = res
+		}
+		cleaned := utils.FormatHCL(code)
+		if err = terraform.CheckTemplate(cleaned); err != nil {
+			return false, fmt.Errorf("error checking cleaned configuration: %w", err)
+		}
+		log.Printf("🧹 Cleaned configuration:\n%s", utils.Diff(generatedFile, generatedFile+" (cleaned)", string(generated), cleaned))
+
+		action, err := userActionPrompt()
+		if err != nil {
+			return false, err
+		}
+		switch action {
+		case dontApply:
+			if planErr != nil {
+				return false, fmt.Errorf("error generating configuration: %w", planErr)
+			}
+			return false, nil
+		case apply:
+			if err = lintTemplate(generatedFile, cleaned); err != nil {
+				return false, err
+			}
+			return true, storeTemplate(path, cleaned)
+		default:
+			prompts = append(prompts, action)
+		}
+	}
+}
+
+// applyImport shows the planned import and applies it once the user approves
+//
+//	@param ctx
+//	@param plan
+//	@return error
+func applyImport(ctx context.Context, plan *tfjson.Plan) error {
+	if plan != nil {
+		log.Printf("📋 Planned changes:\n%s\n", terraform.FormatChanges(terraform.SummarizePlan(plan)))
+	}
+	log.Printf("Apply to import the resources into the state?\n")
+	action, err := userActionPrompt()
+	if err != nil {
+		return err
+	}
+	if action != apply {
+		log.Printf("Run `terraform apply` to import the resources later, %s can be removed afterwards.\n", importsFile)
+		return nil
+	}
+	if err = applyTerraform(ctx); err != nil {
+		return fmt.Errorf("error importing resources: %w", err)
+	}
+	log.Printf("✅ Imported. %s can be removed now, the resources are in the state.\n", importsFile)
+	return nil
+}
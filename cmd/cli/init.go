@@ -1,14 +1,13 @@
 package cli
 
 import (
-	"context"
 	"fmt"
 	"log"
-	"os"
-	"os/signal"
+	"pradytpk/go-terraform-ai/pkg/audit"
 	"pradytpk/go-terraform-ai/pkg/terraform"
-	"pradytpk/go-terraform-ai/pkg/utils"
+	"strings"
 
+	"github.com/manifoldco/promptui"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
@@ -16,8 +15,20 @@ import (
 // Constant string for the init subcommand description
 const initSubCommand = "You are a Terraform HCL generator, only generate valid provider Terraform HCL templates."
 
-// Error for invalid length
-var errLength = errors.New("invalid length")
+// backendSubCommand is appended to the init subcommand description when --backend is set
+const backendSubCommand = " Include a terraform block with a backend %q block, leave out the settings passed with -backend-config: %s."
+
+var (
+	// Error for invalid length
+	errLength = errors.New("invalid length")
+
+	// backend is the type of backend block the generator should emit
+	backend string
+	// initOptions holds the init subcommand flags
+	initOptions terraform.InitOptions
+	// forceCopy migrates the state without confirmation
+	forceCopy bool
+)
 
 // addInit
 //
@@ -28,6 +39,12 @@ func addInit() *cobra.Command {
 		Short: "Run terraform init",
 		RunE:  initCommand,
 	}
+	initCmd.Flags().StringVar(&backend, "backend", "", "Generate a backend block of this type, e.g. s3, azurerm or gcs.")
+	initCmd.Flags().StringArrayVar(&initOptions.BackendConfigs, "backend-config", nil, "Backend configuration passed to terraform init, a file or a key=value pair. Can be repeated.")
+	initCmd.Flags().BoolVar(&initOptions.Reconfigure, "reconfigure", false, "Reconfigure the backend, ignoring any saved configuration.")
+	initCmd.Flags().BoolVar(&initOptions.MigrateState, "migrate-state", false, "Migrate the existing state to the new backend, overwriting any state already in it, after confirmation.")
+	initCmd.Flags().BoolVar(&forceCopy, "force-copy", false, "Migrate the existing state to the new backend, overwriting any state already in it, without confirmation.")
+	initCmd.Flags().BoolVar(&initOptions.Upgrade, "upgrade", false, "Upgrade modules and providers to the newest allowed versions.")
 	return initCmd
 }
 
@@ -48,16 +65,20 @@ func initCommand(_ *cobra.Command, args []string) error {
 //	@param args
 //	@return error
 func initCmd(args []string) error {
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	ctx, cancel := interruptContext()
 	defer cancel()
 	oaiClients, err := newOAIClients()
 	if err != nil {
 		return fmt.Errorf("error creating new OAI clients:%w", err)
 	}
+	subcommand := initSubCommand
+	if backend != "" {
+		subcommand += fmt.Sprintf(backendSubCommand, backend, backendConfigKeys())
+	}
 	var action, com string
 	for action != apply {
 		args = append(args, action)
-		com, err = completion(ctx, oaiClients, args, *openAIDeploymentName, initSubCommand)
+		com, err = completion(ctx, oaiClients, args, *openAIDeploymentName, subcommand)
 		if err != nil {
 			return fmt.Errorf("error completion:%w", err)
 		}
@@ -73,15 +94,73 @@ func initCmd(args []string) error {
 		if err = terraform.CheckTemplate(com); err != nil {
 			return fmt.Errorf("error checking template:%w", err)
 		}
-		if err = utils.StoreFile("provide.tf", com); err != nil {
-			return fmt.Errorf("error store file:%w", err)
+		if err = lintTemplate("provide.tf", com); err != nil {
+			return err
+		}
+		if err = storeTemplate("provide.tf", com); err != nil {
+			return err
 		}
 		if err = terraform.CheckTemplate(com); err != nil {
 			return fmt.Errorf("error checking template:%w", err)
 		}
-		if err = ops.Init(); err != nil {
+		initOptions.MigrateState = initOptions.MigrateState || forceCopy
+		if err = terraform.ValidateBackend(*workingDir, initOptions); err != nil {
+			return fmt.Errorf("error validating backend:%w", err)
+		}
+		var confirmed bool
+		if confirmed, err = confirmMigration(); err != nil {
+			return err
+		}
+		if !confirmed {
+			log.Println("State migration cancelled.")
+			return nil
+		}
+		err = ops.Init(ctx, initOptions)
+		if auditErr := auditResult(audit.EventInit, err); auditErr != nil && err == nil {
+			return auditErr
+		}
+		if err != nil {
 			return fmt.Errorf("error running terraform init:%w", err)
 		}
 	}
 	return nil
 }
+
+// confirmMigration asks before the state is migrated to the new backend, it is true when no state
+// is migrated or with --force-copy. Without confirmation --migrate-state is refused.
+//
+//	@return bool
+//	@return error
+func confirmMigration() (bool, error) {
+	if !initOptions.MigrateState || forceCopy {
+		return true, nil
+	}
+	if !*requireConfirmation {
+		return false, errors.Wrap(errFlag, "--migrate-state needs confirmation, use --force-copy to migrate the state without it")
+	}
+	prompt := promptui.Prompt{
+		Label:     "Migrate the existing state to the new backend, overwriting any state already in it",
+		IsConfirm: true,
+	}
+	// a "no" answer is returned as an error by promptui
+	_, err := prompt.Run()
+	return err == nil, nil
+}
+
+// backendConfigKeys lists the keys given with --backend-config key=value, or "none"
+//
+//	@return string
+func backendConfigKeys() string {
+	var keys []string
+	for _, config := range initOptions.BackendConfigs {
+		key, _, found := strings.Cut(config, "=")
+		if !found {
+			key = "the settings in " + config
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return "none"
+	}
+	return strings.Join(keys, ", ")
+}
@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"pradytpk/go-terraform-ai/pkg/credentials"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// openAIAccount is the keyring account of the OpenAI service, Azure keys are stored per endpoint host
+const openAIAccount = "openai"
+
+var (
+	// Error for a missing API key
+	errAPIKey = errors.New("no API key")
+	// loginStdin reads the key from stdin instead of prompting for it
+	loginStdin bool
+)
+
+// addLogin
+//
+//	@return *cobra.Command
+func addLogin() *cobra.Command {
+	loginCmd := &cobra.Command{
+		Use:   "login",
+		Short: "Store the API key of OpenAI, or of the Azure OpenAI endpoint, in the OS keyring",
+		Args:  cobra.NoArgs,
+		// login needs no terraform
+		PersistentPreRunE: configPreRun,
+		RunE:              loginCommand,
+	}
+	loginCmd.Flags().BoolVar(&loginStdin, "stdin", false, "Read the key from stdin instead of prompting for it.")
+	return loginCmd
+}
+
+// addLogout
+//
+//	@return *cobra.Command
+func addLogout() *cobra.Command {
+	logoutCmd := &cobra.Command{
+		Use:               "logout",
+		Short:             "Remove the API key of OpenAI, or of the Azure OpenAI endpoint, from the OS keyring",
+		Args:              cobra.NoArgs,
+		PersistentPreRunE: configPreRun,
+		RunE:              logoutCommand,
+	}
+	return logoutCmd
+}
+
+// loginCommand is a function that handles the "login" command in the CLI
+//
+//	@param _
+//	@param _
+//	@return error
+func loginCommand(_ *cobra.Command, _ []string) error {
+	var (
+		key string
+		err error
+	)
+	if loginStdin {
+		key, err = bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && key == "" {
+			return fmt.Errorf("error reading the key from stdin:%w", err)
+		}
+	} else {
+		prompt := promptui.Prompt{Label: fmt.Sprintf("API key for %s", keyAccount()), Mask: '*'}
+		if key, err = prompt.Run(); err != nil {
+			return fmt.Errorf("error to run the prompt:%w", err)
+		}
+	}
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return errors.Wrap(errAPIKey, "the key is empty")
+	}
+	if err = credentials.Store(keyAccount(), key); err != nil {
+		return err
+	}
+	log.Printf("🔑 Stored the API key for %s in the OS keyring\n", keyAccount())
+	return nil
+}
+
+// logoutCommand is a function that handles the "logout" command in the CLI
+//
+//	@param _
+//	@param _
+//	@return error
+func logoutCommand(_ *cobra.Command, _ []string) error {
+	deleted, err := credentials.Delete(keyAccount())
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		log.Printf("No API key stored for %s\n", keyAccount())
+		return nil
+	}
+	log.Printf("🔑 Removed the API key for %s from the OS keyring\n", keyAccount())
+	return nil
+}
+
+// apiKey returns the API key from, in order, --open-ai-key or OPENAI_API_KEY, the credential
+// helper of --api-key-command, the file of --api-key-file and the OS keyring
+//
+//	@return string
+//	@return error
+func apiKey() (string, error) {
+	switch {
+	case *openAIAPIKey != "":
+		return *openAIAPIKey, nil
+	case *apiKeyCommand != "":
+		return credentials.FromCommand(context.Background(), *apiKeyCommand)
+	case *apiKeyFile != "":
+		return credentials.FromFile(*apiKeyFile)
+	}
+	key, err := credentials.FromKeyring(keyAccount())
+	if err != nil {
+		return "", errors.Wrapf(errAPIKey, "%s, set OPENAI_API_KEY, --api-key-command or --api-key-file", err)
+	}
+	if key == "" {
+		return "", errors.Wrap(errAPIKey, "run terraform-assistant login, or set OPENAI_API_KEY, --api-key-command or --api-key-file")
+	}
+	return key, nil
+}
+
+// keyAccount returns the keyring account of the service in use, the host of the Azure OpenAI endpoint or openai
+//
+//	@return string
+func keyAccount() string {
+	if *azureOpenAIEndpoint == "" {
+		return openAIAccount
+	}
+	if u, err := url.Parse(*azureOpenAIEndpoint); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return *azureOpenAIEndpoint
+}
@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"pradytpk/go-terraform-ai/pkg/terraform"
+	"pradytpk/go-terraform-ai/pkg/utils"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const moduleSubCommand = "You are a Terraform HCL generator writing a reusable Terraform module. " +
+	"Start with a one paragraph description of what the module creates. Then give each of the files main.tf, variables.tf, outputs.tf and versions.tf " +
+	"in its own ```hcl code block whose first line is a comment like `# file: main.tf`. " +
+	"Every variable and output has a description and every variable a type, values that callers may want to change are variables with sensible defaults, " +
+	"versions.tf has the required_version and required_providers blocks with version constraints and no provider configuration. " +
+	"Do not configure providers or backends in the module.\n"
+
+// moduleNameRegex matches the names of the module directories, they stay inside the working dir
+var moduleNameRegex = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// fileHeaderRegex matches the `# file: main.tf` line starting a generated file
+var fileHeaderRegex = regexp.MustCompile(`^\s*(?:#|//)\s*file:\s*(\S+)\s*$`)
+
+// addModule
+//
+//	@return *cobra.Command
+func addModule() *cobra.Command {
+	moduleCmd := &cobra.Command{
+		Use:   "module <name> <prompt>",
+		Short: "Generate a module (main.tf, variables.tf, outputs.tf, versions.tf and README.md) into the directory <name>",
+		Args:  cobra.MinimumNArgs(2),
+		RunE:  moduleCommand,
+	}
+	return moduleCmd
+}
+
+// moduleCommand is a function that handles the "module" command in the CLI
+//
+//	@param _
+//	@param args
+//	@return error
+func moduleCommand(_ *cobra.Command, args []string) error {
+	if len(args) < 2 {
+		return errors.Wrap(errLength, "module name and prompt must be provided")
+	}
+	return module(args[0], args[1:])
+}
+
+// checkModuleName rejects the names that are not a single lowercase directory name
+//
+//	@param name
+//	@return error
+func checkModuleName(name string) error {
+	if !moduleNameRegex.MatchString(name) {
+		return errors.Wrapf(terraform.ErrModule, "module name %q must only contain lowercase letters, digits, _ and -", name)
+	}
+	return nil
+}
+
+// module generates the files of a module, lets the user review them and writes them with
+// a README into the module directory, then validates the module in isolation
+//
+//	@param name
+//	@param args
+//	@return error
+func module(name string, args []string) error {
+	if err := checkModuleName(name); err != nil {
+		return err
+	}
+	dir := filepath.Join(*workingDir, name)
+	for _, file := range append(terraform.ModuleFiles, "README.md") {
+		if _, err := os.Stat(filepath.Join(dir, file)); err == nil {
+			return errors.Wrapf(terraform.ErrModule, "%s already exists in %s", file, dir)
+		}
+	}
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	oaiClients, err := newOAIClients()
+	if err != nil {
+		return fmt.Errorf("error creating new OAI client: %w", err)
+	}
+
+	var (
+		action, description string
+		files               map[string]string
+	)
+	for action != apply {
+		args = append(args, action)
+		res, err := completion(ctx, oaiClients, args, *openAIDeploymentName, moduleSubCommand)
+		if err != nil {
+			return fmt.Errorf("error completing module command: %w", err)
+		}
+		description, files, err = splitModule(res)
+		if err != nil {
+			return err
+		}
+		for _, file := range terraform.ModuleFiles {
+			if err = terraform.CheckTemplate(files[file]); err != nil {
+				return fmt.Errorf("error checking %s: %w", file, err)
+			}
+			if err = lintTemplate(file, files[file]); err != nil {
+				return err
+			}
+		}
+
+		log.Printf("\n📦 Module %s: %s\n", name, description)
+		for _, file := range terraform.ModuleFiles {
+			log.Printf("\n# file: %s\n%s\n", file, files[file])
+		}
+		action, err = userActionPrompt()
+		if err != nil {
+			return err
+		}
+		if action == dontApply {
+			return nil
+		}
+	}
+
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("error creating %s: %w", dir, err)
+	}
+	for _, file := range terraform.ModuleFiles {
+		if err = storeTemplate(filepath.Join(dir, file), files[file]); err != nil {
+			return err
+		}
+	}
+	readme, err := terraform.ModuleReadme(name, description, dir)
+	if err != nil {
+		return fmt.Errorf("error generating README: %w", err)
+	}
+	if err = recordChange(filepath.Join(dir, "README.md"), []byte(readme)); err != nil {
+		return err
+	}
+	if err = os.WriteFile(filepath.Join(dir, "README.md"), []byte(readme), 0o600); err != nil {
+		return fmt.Errorf("error writing README: %w", err)
+	}
+
+	log.Printf("🔎 Validating module %s\n", name)
+	if err = terraform.ValidateModule(ctx, dir, *execDir); err != nil {
+		return fmt.Errorf("error validating module: %w", err)
+	}
+	log.Printf("✅ Module %s written to %s\n", name, dir)
+	return nil
+}
+
+// splitModule splits the model response into the module description and the content of each module file
+//
+//	@param res
+//	@return string
+//	@return map[string]string
+//	@return error
+func splitModule(res string) (string, map[string]string, error) {
+	files := map[string]string{}
+	for _, match := range codeBlockRegex.FindAllStringSubmatch(res, -1) {
+		header, body, _ := strings.Cut(match[1], "\n")
+		m := fileHeaderRegex.FindStringSubmatch(header)
+		if m == nil {
+			continue
+		}
+		files[filepath.Base(m[1])] = utils.FormatHCL(body)
+	}
+	for _, file := range terraform.ModuleFiles {
+		if _, ok := files[file]; !ok {
+			return "", nil, errors.Wrapf(terraform.ErrModule, "the response has no %s", file)
+		}
+	}
+	description := strings.TrimSpace(codeBlockRegex.ReplaceAllString(res, ""))
+	return description, files, nil
+}
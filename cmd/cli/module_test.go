@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"pradytpk/go-terraform-ai/pkg/terraform"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestCheckModuleName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: "storage"},
+		{name: "storage_account-v2"},
+		{name: "", wantErr: true},
+		{name: "..", wantErr: true},
+		{name: "../outside", wantErr: true},
+		{name: "/etc", wantErr: true},
+		{name: "nested/module", wantErr: true},
+		{name: "Storage", wantErr: true},
+		{name: "my module", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkModuleName(tt.name)
+			if tt.wantErr && !errors.Is(err, terraform.ErrModule) {
+				t.Fatalf("checkModuleName(%q) error = %v, want %v", tt.name, err, terraform.ErrModule)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("checkModuleName(%q) error = %v", tt.name, err)
+			}
+		})
+	}
+}
@@ -24,7 +24,7 @@ var errResp = errors.New("invalid response")
 //	@return string
 //	@return error
 func (c *oaiClients) openaiGptCompletion(ctx context.Context, prompt strings.Builder, maxTokens *int, temp float32) (string, error) {
-	resp, err := c.openAIClient.CompletionWithEngine(ctx, *openAIDeploymentName, openai.CompletionRequest{
+	resp, err := c.openAIClient.CompletionWithEngine(ctx, modelName(*openAIDeploymentName), openai.CompletionRequest{
 		Prompt:      []string{prompt.String()},
 		MaxTokens:   maxTokens,
 		Echo:        false,
@@ -53,7 +53,7 @@ func (c *oaiClients) openaiGptCompletion(ctx context.Context, prompt strings.Bui
 //	@return error
 func (c *oaiClients) openaiGptChatCompletion(ctx context.Context, prompt strings.Builder, maxTokens *int, temp float32) (string, error) {
 	resp, err := c.openAIClient.ChatCompletion(ctx, openai.ChatCompletionRequest{
-		Model: *openAIDeploymentName,
+		Model: modelName(*openAIDeploymentName),
 		Messages: []openai.ChatCompletionRequestMessage{
 			{
 				Role:    userRole,
@@ -114,7 +114,7 @@ func (c *oaiClients) azureGptCompletion(ctx context.Context, prompt strings.Buil
 //	@return error
 func (c *oaiClients) azureGptChatCompletion(ctx context.Context, prompt strings.Builder, maxTokens *int, temp float32) (string, error) {
 	resp, err := c.azureClient.ChatCompletion(ctx, azureopenai.ChatCompletionRequest{
-		Model: *openAIDeploymentName,
+		Model: modelName(*openAIDeploymentName),
 		Messages: []azureopenai.ChatCompletionRequestMessage{
 			{
 				Role:    userRole,
@@ -135,3 +135,110 @@ func (c *oaiClients) azureGptChatCompletion(ctx context.Context, prompt strings.
 
 	return resp.Choices[0].Message.Content, nil
 }
+
+// openaiToolChat sends the conversation and the tools to the OpenAI API, which calls tools with its
+// function calling API, one call per response
+//
+//	@receiver c
+//	@param ctx
+//	@param messages
+//	@param tools
+//	@param maxTokens
+//	@param temp
+//	@return azureopenai.ChatCompletionResponseMessage
+//	@return error
+func (c *oaiClients) openaiToolChat(ctx context.Context, messages []azureopenai.ChatCompletionRequestMessage, tools []azureopenai.Tool, maxTokens int, temp float32) (azureopenai.ChatCompletionResponseMessage, error) {
+	functions := make([]openai.ChatCompletionFunctions, 0, len(tools))
+	for _, t := range tools {
+		properties := make(map[string]openai.FunctionParameterPropertyMetadata, len(t.Function.Parameters.Properties))
+		for name, p := range t.Function.Parameters.Properties {
+			properties[name] = openai.FunctionParameterPropertyMetadata{Type: p.Type, Description: p.Description}
+		}
+		functions = append(functions, openai.ChatCompletionFunctions{
+			Name:        t.Function.Name,
+			Description: t.Function.Description,
+			Parameters: openai.ChatCompletionFunctionParameters{
+				Type:       t.Function.Parameters.Type,
+				Properties: properties,
+				Required:   append([]string{}, t.Function.Parameters.Required...),
+			},
+		})
+	}
+
+	// the function messages are named after the function instead of referring to the call id
+	names := map[string]string{}
+	request := make([]openai.ChatCompletionRequestMessage, 0, len(messages))
+	for _, m := range messages {
+		switch {
+		case len(m.ToolCalls) > 0:
+			call := m.ToolCalls[0]
+			names[call.ID] = call.Function.Name
+			request = append(request, openai.ChatCompletionRequestMessage{
+				Role:         m.Role,
+				Content:      m.Content,
+				FunctionCall: &openai.Function{Name: call.Function.Name, Arguments: call.Function.Arguments},
+			})
+		case m.Role == toolRole:
+			request = append(request, openai.ChatCompletionRequestMessage{Role: functionRole, Name: names[m.ToolCallID], Content: m.Content})
+		default:
+			request = append(request, openai.ChatCompletionRequestMessage{Role: m.Role, Content: m.Content})
+		}
+	}
+
+	resp, err := c.openAIClient.ChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model:       modelName(*openAIDeploymentName),
+		Messages:    request,
+		Functions:   functions,
+		MaxTokens:   maxTokens,
+		N:           1,
+		Temperature: &temp,
+	})
+	if err != nil {
+		return azureopenai.ChatCompletionResponseMessage{}, fmt.Errorf("error openai gpt completion:%w", err)
+	}
+	if len(resp.Choices) != 1 {
+		return azureopenai.ChatCompletionResponseMessage{}, errors.Wrapf(errResp, "expected choices to be 1 but received: %d", len(resp.Choices))
+	}
+	message := resp.Choices[0].Message
+	reply := azureopenai.ChatCompletionResponseMessage{Role: message.Role, Content: message.Content}
+	if message.FunctionCall != nil {
+		reply.ToolCalls = []azureopenai.ToolCall{{
+			ID:       fmt.Sprintf("call_%d", len(messages)),
+			Type:     toolTypeFunction,
+			Function: azureopenai.ToolCallFunction{Name: message.FunctionCall.Name, Arguments: message.FunctionCall.Arguments},
+		}}
+	}
+	return reply, nil
+}
+
+// azureToolChat sends the conversation and the tools to the Azure API
+//
+//	@receiver c
+//	@param ctx
+//	@param messages
+//	@param tools
+//	@param maxTokens
+//	@param temp
+//	@return azureopenai.ChatCompletionResponseMessage
+//	@return error
+func (c *oaiClients) azureToolChat(ctx context.Context, messages []azureopenai.ChatCompletionRequestMessage, tools []azureopenai.Tool, maxTokens int, temp float32) (azureopenai.ChatCompletionResponseMessage, error) {
+	request := azureopenai.ChatCompletionRequest{
+		Model:       modelName(*openAIDeploymentName),
+		Messages:    messages,
+		Tools:       tools,
+		MaxTokens:   maxTokens,
+		N:           1,
+		Temperature: &temp,
+	}
+	if len(tools) > 0 {
+		request.ToolChoice = "auto"
+	}
+	resp, err := c.azureClient.ChatCompletion(ctx, request)
+	if err != nil {
+		return azureopenai.ChatCompletionResponseMessage{}, fmt.Errorf("error azure chatgpt completion: %w", err)
+	}
+	if len(resp.Choices) != 1 {
+		return azureopenai.ChatCompletionResponseMessage{}, errors.Wrapf(errResp, "expected choices to be 1 but received: %d", len(resp.Choices))
+	}
+	return resp.Choices[0].Message, nil
+}
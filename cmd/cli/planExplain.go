@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"pradytpk/go-terraform-ai/pkg/terraform"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/spf13/cobra"
+)
+
+const planExplainSubCommand = "You are a Terraform expert reviewing a plan for a change advisory board. " +
+	"Summarize the following condensed list of planned changes in plain language and call out the risks: " +
+	"forced replacements (and which attribute forces them), destroyed resources, possible data loss and changes likely to cause downtime. " +
+	"Finish with a one line risk rating: low, medium or high.\n"
+
+// planReportFile is where the plan-explain report is written, empty prints it only
+var planReportFile string
+
+// addPlanExplain
+//
+//	@return *cobra.Command
+func addPlanExplain() *cobra.Command {
+	planExplainCmd := &cobra.Command{
+		Use:   "plan-explain [planfile]",
+		Short: "Explain a terraform plan in plain language, running plan when no plan file is given",
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  planExplainCommand,
+	}
+	planExplainCmd.Flags().StringVarP(&planReportFile, "out", "o", "", "Also write the report as markdown to this file.")
+	return planExplainCmd
+}
+
+// planExplainCommand is a function that handles the "plan-explain" command in the CLI
+//
+//	@param _
+//	@param args
+//	@return error
+func planExplainCommand(_ *cobra.Command, args []string) error {
+	planFile := ""
+	if len(args) == 1 {
+		planFile = args[0]
+	}
+	return planExplain(planFile)
+}
+
+// planExplain condenses the plan into a change list and asks the model to summarize its risks
+//
+//	@param planFile
+//	@return error
+func planExplain(planFile string) error {
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	plan, err := loadPlan(ctx, planFile)
+	if err != nil {
+		return err
+	}
+	changes := terraform.FormatChanges(terraform.SummarizePlan(plan))
+	log.Printf("📋 Planned changes:\n%s\n", changes)
+
+	oaiClients, err := newOAIClients()
+	if err != nil {
+		return fmt.Errorf("error creating new OAI client: %w", err)
+	}
+	res, err := completion(ctx, oaiClients, []string{changes}, *openAIDeploymentName, planExplainSubCommand)
+	if err != nil {
+		return fmt.Errorf("error completing plan-explain command: %w", err)
+	}
+	explanation := strings.TrimSpace(res)
+	fmt.Println(explanation)
+
+	if planReportFile == "" {
+		return nil
+	}
+	report := fmt.Sprintf("# Plan explanation\n\n## Planned changes\n\n```\n%s\n```\n\n## Summary\n\n%s\n", changes, explanation)
+	if err = os.WriteFile(planReportFile, []byte(report), 0o600); err != nil {
+		return fmt.Errorf("error writing report: %w", err)
+	}
+	log.Printf("💾 Report written to %s\n", planReportFile)
+	return nil
+}
+
+// loadPlan runs plan when planFile is empty, otherwise reads the binary plan file or its
+// `terraform show -json` output
+//
+//	@param ctx
+//	@param planFile
+//	@return *tfjson.Plan
+//	@return error
+func loadPlan(ctx context.Context, planFile string) (*tfjson.Plan, error) {
+	if planFile == "" {
+		plan, err := ops.Plan(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error running plan: %w", err)
+		}
+		return plan, nil
+	}
+	raw, err := os.ReadFile(planFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading plan file: %w", err)
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{")) {
+		plan := &tfjson.Plan{}
+		if err = json.Unmarshal(raw, plan); err != nil {
+			return nil, fmt.Errorf("error decoding plan json: %w", err)
+		}
+		return plan, nil
+	}
+	// terraform runs in the working dir, so the path must not be relative to the current dir
+	planFile, err = filepath.Abs(planFile)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving plan file: %w", err)
+	}
+	plan, err := ops.ShowPlan(ctx, planFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading plan file: %w", err)
+	}
+	return plan, nil
+}
@@ -0,0 +1,221 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"pradytpk/go-terraform-ai/pkg/review"
+	"pradytpk/go-terraform-ai/pkg/terraform"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	reviewSubCommand = "You are a Terraform expert doing a code review of the following changes (a git diff of .tf files). " +
+		"Look for security problems, bugs, missing tags or descriptions, hardcoded values that should be variables, breaking changes and deviations from Terraform best practices. " +
+		"Only comment on added or changed lines. The findings of the static lint are listed too, do not repeat them. " +
+		"Answer only with a JSON array of issues, each an object with the keys file, line (the line number in the new file), severity (low, medium, high or critical), message and suggestion. " +
+		"Answer with [] when there are no issues.\n"
+
+	reviewFormatText  = "text"
+	reviewFormatJSON  = "json"
+	reviewFormatSARIF = "sarif"
+)
+
+var errReview = errors.New("review failed")
+
+var (
+	// reviewBase is the git revision the changes are compared against
+	reviewBase string
+	// reviewFormat is one of text, json or sarif
+	reviewFormat string
+	// reviewOutFile is where the review is written, empty prints it
+	reviewOutFile string
+	// reviewFailOn is the severity from which the review fails
+	reviewFailOn string
+)
+
+// addReview
+//
+//	@return *cobra.Command
+func addReview() *cobra.Command {
+	reviewCmd := &cobra.Command{
+		Use:   "review",
+		Short: "Review the changes to the .tf files of the working dir against a git base revision",
+		Args:  cobra.NoArgs,
+		RunE:  reviewCommand,
+		// review only reads git and the files
+		PersistentPreRunE: preRun,
+	}
+	reviewCmd.Flags().StringVar(&reviewBase, "base", "main", "The git revision the changes are compared against.")
+	reviewCmd.Flags().StringVarP(&reviewFormat, "format", "f", reviewFormatText, "The output format: text, json or sarif.")
+	reviewCmd.Flags().StringVarP(&reviewOutFile, "out", "o", "", "Write the review to this file instead of printing it.")
+	reviewCmd.Flags().StringVar(&reviewFailOn, "fail-on", "none", "Fail when an issue is at least this severe: low, medium, high, critical or none.")
+	return reviewCmd
+}
+
+// reviewCommand is a function that handles the "review" command in the CLI
+//
+//	@param _
+//	@param _
+//	@return error
+func reviewCommand(_ *cobra.Command, _ []string) error {
+	return reviewChanges()
+}
+
+// reviewChanges combines the lint findings of the changed files with the review of the model
+// and writes them in the requested format
+//
+//	@return error
+func reviewChanges() error {
+	switch reviewFormat {
+	case reviewFormatText, reviewFormatJSON, reviewFormatSARIF:
+	default:
+		return errors.Wrapf(errFlag, "unknown format %q", reviewFormat)
+	}
+	failOn, err := terraform.ParseSeverity(reviewFailOn)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	changes, err := review.Diff(ctx, *workingDir, reviewBase)
+	if err != nil {
+		return fmt.Errorf("error computing the changes: %w", err)
+	}
+
+	var issues []review.Issue
+	if len(changes.Files) == 0 {
+		log.Printf("No .tf changes against %s\n", reviewBase)
+	} else {
+		issues = lintChanges(changes)
+		aiIssues, err := aiReview(ctx, changes, issues)
+		if err != nil {
+			return err
+		}
+		issues = append(issues, aiIssues...)
+	}
+	review.Sort(issues)
+
+	if err = writeReview(issues, changes.Prefix); err != nil {
+		return err
+	}
+	for _, issue := range issues {
+		if failOn > 0 && issue.Severity >= failOn {
+			return errors.Wrapf(errReview, "found issues of severity %s or higher", failOn)
+		}
+	}
+	return nil
+}
+
+// lintChanges runs the lint rules against the changed files that still exist
+//
+//	@param changes
+//	@return []review.Issue
+func lintChanges(changes review.Changes) []review.Issue {
+	var issues []review.Issue
+	for _, f := range changes.Files {
+		src, err := os.ReadFile(filepath.Join(*workingDir, f.File))
+		if err != nil {
+			// deleted files have nothing to lint
+			continue
+		}
+		findings, err := terraform.Lint(f.File, src)
+		if err != nil {
+			log.Printf("⚠️ Could not lint %s: %s\n", f.File, err)
+			continue
+		}
+		issues = append(issues, review.FromFindings(f.File, findings)...)
+	}
+	return issues
+}
+
+// aiReview sends the diffs, in chunks of whole files, with the lint findings to the model
+//
+//	@param ctx
+//	@param changes
+//	@param lintIssues
+//	@return []review.Issue
+//	@return error
+func aiReview(ctx context.Context, changes review.Changes, lintIssues []review.Issue) ([]review.Issue, error) {
+	oaiClients, err := newOAIClients()
+	if err != nil {
+		return nil, fmt.Errorf("error creating new OAI client: %w", err)
+	}
+	findings := make([]string, 0, len(lintIssues))
+	for _, issue := range lintIssues {
+		findings = append(findings, issue.String())
+	}
+	lint := "Static lint findings:\n" + strings.Join(findings, "\n")
+	if len(findings) == 0 {
+		lint = "Static lint findings: none"
+	}
+
+	var chunks []string
+	var current strings.Builder
+	for _, f := range changes.Files {
+		if current.Len() > 0 && current.Len()+len(f.Diff) > chunkChars() {
+			chunks = append(chunks, current.String())
+			current.Reset()
+		}
+		current.WriteString(f.Diff)
+	}
+	chunks = append(chunks, current.String())
+
+	var issues []review.Issue
+	for i, chunk := range chunks {
+		if len(chunks) > 1 {
+			log.Printf("🔍 Reviewing part %d of %d\n", i+1, len(chunks))
+		}
+		res, err := completion(ctx, oaiClients, []string{lint, chunk}, *openAIDeploymentName, reviewSubCommand)
+		if err != nil {
+			return nil, fmt.Errorf("error completing review command: %w", err)
+		}
+		chunkIssues, err := review.ParseIssues(res)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, chunkIssues...)
+	}
+	return issues, nil
+}
+
+// writeReview writes the issues in the requested format to the output file or stdout
+//
+//	@param issues
+//	@param prefix
+//	@return error
+func writeReview(issues []review.Issue, prefix string) error {
+	var w io.Writer = os.Stdout
+	if reviewOutFile != "" {
+		file, err := os.Create(reviewOutFile)
+		if err != nil {
+			return fmt.Errorf("error creating %s: %w", reviewOutFile, err)
+		}
+		defer file.Close()
+		w = file
+	}
+	var err error
+	switch reviewFormat {
+	case reviewFormatJSON:
+		err = review.WriteJSON(w, issues)
+	case reviewFormatSARIF:
+		err = review.WriteSARIF(w, issues, prefix)
+	default:
+		err = review.WriteText(w, issues)
+	}
+	if err != nil {
+		return fmt.Errorf("error writing the review: %w", err)
+	}
+	if reviewOutFile != "" {
+		log.Printf("📝 Review written to %s\n", reviewOutFile)
+	}
+	return nil
+}
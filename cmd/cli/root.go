@@ -2,46 +2,93 @@ package cli
 
 import (
 	"flag"
+	"fmt"
 	"log"
+	"os"
+	azureopenai "pradytpk/go-terraform-ai/pkg/gpt3"
 	"pradytpk/go-terraform-ai/pkg/terraform"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/walles/env"
 )
 
 const version = "0.0.2"
 
+// Values of the --terraform-output flag
+const (
+	outputProgress = "progress"
+	outputJSON     = "json"
+	outputRaw      = "raw"
+)
+
+var (
+	// Error for invalid flag values
+	errFlag = errors.New("invalid flag")
+	// Error for a missing terraform executable
+	errTerraform = errors.New("terraform not found")
+)
+
 var (
 	openAIDeploymentName = flag.String("openai-deployment-name", env.GetOr("OPENAI_DEPLOYMENT_NAME", env.String, "text-davinci-003"), "The deployment name used for the model in OpenAI service.")
+	model                = flag.String("model", env.GetOr("OPENAI_MODEL", env.String, ""), "The model behind the deployment, e.g. gpt-4o for an Azure deployment named prod-chat. It sets the token limits and the API used. Defaults to the deployment name.")
 	workingDir           = flag.String("working-dir", env.GetOr("WORKING_DIR", env.String, ""), "The path of the project that you want to run")
 	execDir              = flag.String("exec-dir", env.GetOr("EXEC_DIR", env.String, ""), "The path of the project that you want to run")
-	openAIAPIKey         = flag.String("open-ai-key", env.GetOr("OPENAI_API_KEY", env.String, ""), "The API key for the openai service.This is required")
+	openAIAPIKey         = flag.String("open-ai-key", env.GetOr("OPENAI_API_KEY", env.String, ""), "The API key for the openai service. A key on the command line is visible in the process list and shell history, prefer login, --api-key-command or --api-key-file.")
+	apiKeyCommand        = flag.String("api-key-command", env.GetOr("API_KEY_COMMAND", env.String, ""), "A credential helper command printing the API key, e.g. \"op read op://vault/openai/key\". Used when --open-ai-key is not set.")
+	apiKeyFile           = flag.String("api-key-file", env.GetOr("API_KEY_FILE", env.String, ""), "A file holding the API key, readable only by its owner. Used when --open-ai-key and --api-key-command are not set.")
 	requireConfirmation  = flag.Bool("require-confirmation", env.GetOr("REQUIRE_CONFIRMATION", strconv.ParseBool, true), "Whether to require confirmation before executing the command. Defaults to true.")
 	temperature          = flag.Float64("temperature", env.GetOr("TEMPERATURE", env.WithBitSize(strconv.ParseFloat, 64), 0.0), "The temperature to use for the model. Range is between 0 and 1. Set closer to 0 if your want output to be more deterministic but less creative. Defaults to 0.0.")
 	maxTokens            = flag.Int("max-tokens", env.GetOr("MAX_TOKENS", strconv.Atoi, 0), "The max token will overwrite the max tokens in the max tokens map.")
 	azureOpenAIEndpoint  = flag.String("azure-openai-endpoint", env.GetOr("AZURE_OPENAI_ENDPOINT", env.String, ""), "The endpoint for Azure OpenAI service. If provided, Azure OpenAI service will be used instead of OpenAI service.")
+	azureAPIVersion      = flag.String("azure-api-version", env.GetOr("AZURE_API_VERSION", env.String, ""), "The api-version of the Azure OpenAI requests, e.g. 2024-10-21. Defaults to "+azureopenai.DefaultAPIVersion+".")
+	azureAuth            = flag.String("azure-auth", env.GetOr("AZURE_AUTH", env.String, azureAuthKey), "How to authenticate to Azure OpenAI: key (the API key), client-secret (AZURE_CLIENT_SECRET), workload-identity (AZURE_FEDERATED_TOKEN_FILE) or managed-identity. Defaults to key.")
+	azureTenantID        = flag.String("azure-tenant-id", env.GetOr("AZURE_TENANT_ID", env.String, ""), "The Entra ID tenant of the client-secret and workload-identity auth.")
+	azureClientID        = flag.String("azure-client-id", env.GetOr("AZURE_CLIENT_ID", env.String, ""), "The client id of the app registration, or of the user assigned managed identity.")
+	policyPaths          = flag.String("policy", env.GetOr("POLICY_PATH", env.String, ""), "Comma separated list of CEL policy files or directories evaluated against the plan before apply. Any deny result blocks the apply.")
+	secretsMode          = flag.String("secrets", env.GetOr("SECRETS_MODE", env.String, secretsRedact), "What to do when a prompt contains a credential: redact it before it is sent, or block the request. Generated templates with hardcoded credentials are always blocked. Defaults to redact.")
+	tfVars               = stringSliceFlag("var", "", "Set a variable of the configuration for plan and apply, e.g. --var region=eu-west-1. Can be repeated.")
+	tfVarFiles           = stringSliceFlag("var-file", env.GetOr("VAR_FILE", env.String, ""), "Load variables for plan and apply from a .tfvars file. Can be repeated.")
+	logDir               = flag.String("log-dir", env.GetOr("LOG_DIR", env.String, ""), "The directory of the terraform run logs. Defaults to .terraform-assistant/logs in the working dir.")
+	terraformOutput      = flag.String("terraform-output", env.GetOr("TERRAFORM_OUTPUT", env.String, outputProgress), "How terraform plan and apply output is shown: progress (a line per resource and a summary table), json (the machine readable events as JSON lines) or raw. Defaults to progress.")
+	initTimeout          = flag.Duration("init-timeout", env.GetOr("INIT_TIMEOUT", time.ParseDuration, 0), "The maximum duration of terraform init, e.g. 5m. Defaults to no limit.")
+	planTimeout          = flag.Duration("plan-timeout", env.GetOr("PLAN_TIMEOUT", time.ParseDuration, 0), "The maximum duration of terraform plan, e.g. 10m. Defaults to no limit.")
+	applyTimeout         = flag.Duration("apply-timeout", env.GetOr("APPLY_TIMEOUT", time.ParseDuration, 0), "The maximum duration of terraform apply, e.g. 30m. Defaults to no limit.")
+	interruptGrace       = flag.Duration("interrupt-grace", env.GetOr("INTERRUPT_GRACE", time.ParseDuration, time.Minute), "How long terraform may take to stop cleanly and release the state lock after an interrupt or a timeout before it is killed. Defaults to 1m.")
+	auditLogPath         = flag.String("audit-log", env.GetOr("AUDIT_LOG", env.String, ""), "The hash chained JSONL audit log of prompts, generated files, plans and applies. Defaults to .terraform-assistant/audit.jsonl in the working dir.")
+	auditPromptMode      = flag.String("audit-prompt", env.GetOr("AUDIT_PROMPT", env.String, auditPromptRedact), "How prompts and responses are written to the audit log: redact (credentials redacted), full, or hash (only the SHA-256 of the prompt). Defaults to redact.")
+	profile              = flag.String("profile", env.GetOr("TERRAFORM_ASSISTANT_PROFILE", env.String, ""), "The profile of the config files to use, e.g. azure or openai. Defaults to the profile set in the project or user config file.")
+	resume               = flag.String("resume", "", "Resume a saved session by id, or the most recent one with \"last\", to continue refining it with run or chat.")
+	agentMode            = flag.Bool("agent", env.GetOr("AGENT", strconv.ParseBool, false), "Let chat models call read-only tools (list and read the .tf files, terraform validate, the state and the provider schemas) before answering.")
+	agentSteps           = flag.Int("agent-steps", env.GetOr("AGENT_STEPS", strconv.Atoi, 8), "The maximum rounds of tool calls of --agent before the model must answer. Defaults to 8.")
+	agentTranscript      = flag.String("agent-transcript", env.GetOr("AGENT_TRANSCRIPT", env.String, ""), "A file the tool calls and answers of --agent are appended to as JSON lines.")
+	autoFix              = flag.Bool("auto-fix", env.GetOr("AUTO_FIX", strconv.ParseBool, false), "Apply the fixes proposed for a failed apply without review when --require-confirmation=false. Defaults to false.")
+	lintFailSeverity     = flag.String("lint-fail-severity", env.GetOr("LINT_FAIL_SEVERITY", env.String, "high"), "The minimum severity (low, medium, high, critical or none) of a security lint finding that blocks the generated template. Defaults to high.")
 
 	ops terraform.Ops
 	err error
 )
 
-// InitAndExecute initializes the working directory and execution directory, parses command line flags and executes the root command
+// InitAndExecute initializes the working directory and execution directory and executes the root command,
+// which parses the command line flags. The API key is only required by the commands calling the model.
 //
 //	@param workDir
 //	@param executionDir
 func InitAndExecute(workDir string, executionDir string) {
-	flag.Parse()
 	if *workingDir == "" {
-		workingDir = &workDir
+		*workingDir = workDir
 	}
 	if *execDir == "" {
-		execDir = &executionDir
-	}
-	if *openAIAPIKey == "" {
-		log.Fatal("Please provide an openAI Key")
+		*execDir = executionDir
 	}
 	if err := RootCmd().Execute(); err != nil {
+		if errors.Is(err, errDrift) {
+			log.Println(err.Error())
+			os.Exit(exitDrift)
+		}
 		log.Fatal(err.Error())
 	}
 }
@@ -50,19 +97,100 @@ func InitAndExecute(workDir string, executionDir string) {
 //
 //	@return *cobra.Command
 func RootCmd() *cobra.Command {
-	ops, err = terraform.NewTerraform(*workingDir, *execDir)
-	if err != nil {
-		return nil
-	}
 	cmd := &cobra.Command{
-		Use:          "terraform-assistant",
-		Version:      version,
-		Args:         cobra.MinimumNArgs(1),
-		RunE:         runCommand,
-		SilenceUsage: true,
+		Use:               "terraform-assistant",
+		Version:           version,
+		Args:              cobra.MinimumNArgs(1),
+		PersistentPreRunE: newOps,
+		RunE:              runCommand,
+		SilenceUsage:      true,
 	}
 	cmd.PersistentFlags().AddGoFlagSet(flag.CommandLine)
 	initCmd := addInit()
 	cmd.AddCommand(initCmd)
+	cmd.AddCommand(addExplain())
+	cmd.AddCommand(addPlanExplain())
+	cmd.AddCommand(addReview())
+	cmd.AddCommand(addModule())
+	cmd.AddCommand(addImport())
+	cmd.AddCommand(addDrift())
+	cmd.AddCommand(addChat())
+	cmd.AddCommand(addSessions())
+	cmd.AddCommand(addAudit())
+	cmd.AddCommand(addUndo())
+	cmd.AddCommand(addConfig())
+	cmd.AddCommand(addLogin())
+	cmd.AddCommand(addLogout())
 	return cmd
 }
+
+// newOps applies the config files and creates the terraform operations once all the flags have been parsed
+//
+//	@param cmd
+//	@param args
+//	@return error
+func newOps(cmd *cobra.Command, args []string) error {
+	if err := preRun(cmd, args); err != nil {
+		return err
+	}
+	return createOps()
+}
+
+// preRun applies the config files and names the command in the audit log, it is the pre-run of the
+// commands that run no terraform
+//
+//	@param cmd
+//	@param _
+//	@return error
+func preRun(cmd *cobra.Command, _ []string) error {
+	if err := applyConfig(cmd); err != nil {
+		return err
+	}
+	auditCommand = cmd.Name()
+	if !cmd.HasParent() {
+		auditCommand = commandRun
+	}
+	return nil
+}
+
+// createOps creates the terraform operations, once
+//
+//	@return error
+func createOps() error {
+	if ops != nil {
+		return nil
+	}
+	if *execDir == "" {
+		return errors.Wrap(errTerraform, "install terraform or set --exec-dir to its path")
+	}
+	options := []terraform.Option{
+		terraform.WithVars(*tfVars),
+		terraform.WithVarFiles(*tfVarFiles),
+		terraform.WithLogDir(*logDir),
+		terraform.WithTimeouts(terraform.Timeouts{Init: *initTimeout, Plan: *planTimeout, Apply: *applyTimeout}),
+		terraform.WithInterruptGrace(*interruptGrace),
+		terraform.WithPlanHandler(auditPlan),
+	}
+	switch *terraformOutput {
+	case outputProgress:
+		options = append(options, terraform.WithEvents(terraform.NewProgress(os.Stdout)))
+	case outputJSON:
+		options = append(options, terraform.WithEvents(terraform.NewJSONHandler(os.Stdout)))
+	case outputRaw:
+	default:
+		return errors.Wrapf(errFlag, "unknown terraform-output %q", *terraformOutput)
+	}
+	if *policyPaths != "" {
+		policy, err := terraform.LoadPolicies(strings.Split(*policyPaths, ","))
+		if err != nil {
+			return fmt.Errorf("error loading policies:%w", err)
+		}
+		options = append(options, terraform.WithPolicy(policy))
+	}
+	tf, err := terraform.NewTerraform(*workingDir, *execDir, options...)
+	if err != nil {
+		return fmt.Errorf("error creating terraform:%w", err)
+	}
+	ops = tf
+	return nil
+}
@@ -1,13 +1,12 @@
 package cli
 
 import (
-	"context"
 	"fmt"
 	"log"
-	"os"
-	"os/signal"
+	"pradytpk/go-terraform-ai/pkg/session"
 	"pradytpk/go-terraform-ai/pkg/terraform"
 	"pradytpk/go-terraform-ai/pkg/utils"
+	"strings"
 
 	"github.com/pkg/errors"
 
@@ -39,7 +38,7 @@ func runCommand(_ *cobra.Command, args []string) error {
 //	@return error
 func run(args []string) error {
 	// Create a context with a cancellation function that will be triggered on receiving an interrupt signal.
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	ctx, cancel := interruptContext()
 	defer cancel()
 
 	// Create new OAI clients.
@@ -48,22 +47,43 @@ func run(args []string) error {
 		return fmt.Errorf("error creating new OAI client: %w", err)
 	}
 
+	// Start a new session or resume the one given by --resume, it is saved whatever happens.
+	s, err := startSession(commandRun, "")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := saveSession(); err != nil {
+			log.Printf("⚠️ Could not save session %s: %s\n", s.ID, err)
+		}
+	}()
+	history := resumePrompts(s)
+	if len(args) > 0 {
+		s.Add(session.RoleUser, strings.Join(args, " "))
+	}
+
 	var action, com, name string
 	for action != apply {
 		// Append the current action to the args slice.
 		args = append(args, action)
+		if action != "" {
+			s.Add(session.RoleUser, action)
+		}
 
 		// Get completion for the run subcommand.
 		//this creates the content for the terraform file
-		com, err = completion(ctx, oaiClients, args, *openAIDeploymentName, runSubCommand)
+		com, err = completion(ctx, oaiClients, append(history, args...), *openAIDeploymentName, runSubCommand)
 		if err != nil {
 			return fmt.Errorf("error completing run command: %w", err)
 		}
+		s.Add(session.RoleAssistant, com)
+		s.Push(com)
 
 		// Get completion for the name subcommand.
-		//this just creates names of terraform files
-		name, err = completion(ctx, oaiClients, args, *openAIDeploymentName, nameSubCommand)
-		if err != nil {
+		//this just creates names of terraform files, a resumed session keeps its file
+		if s.File != "" {
+			name = s.File
+		} else if name, err = completion(ctx, oaiClients, args, *openAIDeploymentName, nameSubCommand); err != nil {
 			return fmt.Errorf("error completing name command: %w", err)
 		}
 
@@ -84,21 +104,40 @@ func run(args []string) error {
 	}
 
 	// Check the template for errors.
-	if err = terraform.CheckTemplate(com); err != nil {
+	err = terraform.CheckTemplate(com)
+	recordEvent(session.EventValidation, "check template", err)
+	if err != nil {
 		return fmt.Errorf("error checking template: %w", err)
 	}
 
 	// Get the name from the completion result.
 	name = utils.GetName(name)
+	s.File = name
+
+	// Check the template against the security lint rules, under the name it is stored with.
+	if err = lintTemplate(name, com); err != nil {
+		return err
+	}
 
 	// Store the file with the given name and template.
-	err = utils.StoreFile(name, com)
+	err = storeTemplate(name, com)
 	if err != nil {
-		return fmt.Errorf("error storing file: %w", err)
+		return err
+	}
+
+	// Ask for the required variables that do not have a value yet.
+	if err = promptVariables(); err != nil {
+		return err
 	}
 
 	// Apply the Terraform operations.
-	err = ops.Apply()
+	err = applyTerraform(ctx)
+	if err != nil {
+		// Offer a diagnosis and a fix before giving up.
+		log.Printf("❌ Apply failed: %s\n", err)
+		err = fixFailedApply(ctx, oaiClients, name, err)
+	}
+	recordEvent(session.EventApply, "apply "+name, err)
 	if err != nil {
 		return fmt.Errorf("error applying Terraform: %w", err)
 	}
@@ -0,0 +1,241 @@
+package cli
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"pradytpk/go-terraform-ai/pkg/session"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Commands that record a session
+const (
+	commandRun  = "run"
+	commandChat = "chat"
+)
+
+var (
+	// activeSession is the session of the running command, nil for commands without a session
+	activeSession *session.Session
+	// sessionJSON prints the session as stored by sessions show
+	sessionJSON bool
+)
+
+// addSessions
+//
+//	@return *cobra.Command
+func addSessions() *cobra.Command {
+	sessionsCmd := &cobra.Command{
+		Use:   "sessions",
+		Short: "List, show, resume and delete the saved run and chat sessions",
+		// only resume runs terraform
+		PersistentPreRunE: preRun,
+	}
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the sessions, the most recent first",
+		Args:  cobra.NoArgs,
+		RunE:  sessionsListCommand,
+	}
+	showCmd := &cobra.Command{
+		Use:   "show <id>",
+		Short: "Show the prompts, responses, validations, files, plans and applies of a session",
+		Args:  cobra.ExactArgs(1),
+		RunE:  sessionsShowCommand,
+	}
+	showCmd.Flags().BoolVar(&sessionJSON, "json", false, "Print the session as it is stored.")
+	resumeCmd := &cobra.Command{
+		Use:               "resume <id> [prompt]",
+		Short:             "Continue a session, with the prompt as the next instructions of a run session",
+		Args:              cobra.MinimumNArgs(1),
+		RunE:              sessionsResumeCommand,
+		PersistentPreRunE: newOps,
+	}
+	deleteCmd := &cobra.Command{
+		Use:   "delete <id>...",
+		Short: "Delete sessions",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  sessionsDeleteCommand,
+	}
+	sessionsCmd.AddCommand(listCmd, showCmd, resumeCmd, deleteCmd)
+	return sessionsCmd
+}
+
+// sessionsListCommand is a function that handles the "sessions list" command in the CLI
+//
+//	@param _
+//	@param _
+//	@return error
+func sessionsListCommand(_ *cobra.Command, _ []string) error {
+	sessions, err := session.List(sessionsDir())
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		fmt.Println("No sessions.")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCOMMAND\tUPDATED\tFILE\tTITLE")
+	for _, s := range sessions {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.ID, s.Command, s.Updated.Format(time.DateTime), s.File, s.Title())
+	}
+	return w.Flush()
+}
+
+// sessionsShowCommand is a function that handles the "sessions show" command in the CLI
+//
+//	@param _
+//	@param args
+//	@return error
+func sessionsShowCommand(_ *cobra.Command, args []string) error {
+	s, err := session.Load(sessionsDir(), args[0])
+	if err != nil {
+		return err
+	}
+	if sessionJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(s)
+	}
+	fmt.Printf("Session %s (%s)\nModel:   %s (%s)\nFile:    %s\nCreated: %s\nUpdated: %s\n\n",
+		s.ID, s.Command, cmp.Or(s.Deployment, s.Model), s.Model, s.File, s.Created.Format(time.DateTime), s.Updated.Format(time.DateTime))
+
+	// merge the conversation and the events into one timeline
+	type entry struct {
+		time time.Time
+		text string
+	}
+	entries := make([]entry, 0, len(s.Messages)+len(s.Events))
+	for _, m := range s.Messages {
+		entries = append(entries, entry{m.Time, fmt.Sprintf("%s:\n%s", m.Role, strings.TrimSpace(m.Content))})
+	}
+	for _, e := range s.Events {
+		text := fmt.Sprintf("[%s] %s", e.Kind, e.Detail)
+		if e.Error != "" {
+			text += "\n  ❌ " + e.Error
+		}
+		entries = append(entries, entry{e.Time, text})
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].time.Before(entries[j].time) })
+	for _, e := range entries {
+		fmt.Printf("%s %s\n\n", e.time.Format(time.TimeOnly), e.text)
+	}
+	return nil
+}
+
+// sessionsResumeCommand is a function that handles the "sessions resume" command in the CLI
+//
+//	@param _
+//	@param args
+//	@return error
+func sessionsResumeCommand(_ *cobra.Command, args []string) error {
+	s, err := session.Load(sessionsDir(), args[0])
+	if err != nil {
+		return err
+	}
+	*resume = s.ID
+	if s.Command == commandChat {
+		return chatCommand(nil, nil)
+	}
+	return run(args[1:])
+}
+
+// sessionsDeleteCommand is a function that handles the "sessions delete" command in the CLI
+//
+//	@param _
+//	@param args
+//	@return error
+func sessionsDeleteCommand(_ *cobra.Command, args []string) error {
+	for _, id := range args {
+		if err := session.Delete(sessionsDir(), id); err != nil {
+			return err
+		}
+		log.Printf("🗑️ Deleted session %s\n", id)
+	}
+	return nil
+}
+
+// startSession resumes the session given by --resume or starts a new one, and makes it the active session
+//
+//	@param command
+//	@param file
+//	@return *session.Session
+//	@return error
+func startSession(command string, file string) (*session.Session, error) {
+	if *resume == "" {
+		activeSession = session.New(command, *openAIDeploymentName, modelName(*openAIDeploymentName), file)
+		log.Printf("💬 Session %s\n", activeSession.ID)
+		return activeSession, nil
+	}
+	s, err := session.Load(sessionsDir(), *resume)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case s.Deployment != "":
+		*openAIDeploymentName, *model = s.Deployment, s.Model
+	case s.Model != "":
+		*openAIDeploymentName = s.Model
+	}
+	activeSession = s
+	log.Printf("💬 Resumed session %s (%s), %d messages\n", s.ID, s.Title(), len(s.Messages))
+	return s, nil
+}
+
+// saveSession saves the active session
+//
+//	@return error
+func saveSession() error {
+	if activeSession == nil {
+		return nil
+	}
+	return session.Save(sessionsDir(), activeSession)
+}
+
+// recordEvent records a step in the active session, if any
+//
+//	@param kind
+//	@param detail
+//	@param err
+func recordEvent(kind string, detail string, err error) {
+	if activeSession != nil {
+		activeSession.Record(kind, detail, err)
+	}
+}
+
+// resumePrompts returns the earlier requests and the current configuration of a resumed session
+//
+//	@param s
+//	@return []string
+func resumePrompts(s *session.Session) []string {
+	var requests []string
+	for _, m := range s.Messages {
+		if m.Role == session.RoleUser {
+			requests = append(requests, "- "+m.Content)
+		}
+	}
+	if len(requests) == 0 {
+		return nil
+	}
+	prompts := []string{"Earlier requests:\n" + strings.Join(requests, "\n")}
+	if current := s.Current(); current != "" {
+		prompts = append(prompts, "Current configuration, refine it:\n"+current)
+	}
+	return prompts
+}
+
+// sessionsDir returns the directory of the sessions in the working dir
+//
+//	@return string
+func sessionsDir() string {
+	return filepath.Join(*workingDir, session.DefaultDir)
+}
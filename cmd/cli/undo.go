@@ -0,0 +1,302 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"pradytpk/go-terraform-ai/pkg/audit"
+	"pradytpk/go-terraform-ai/pkg/changes"
+	"pradytpk/go-terraform-ai/pkg/terraform"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/manifoldco/promptui"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// errUndo is returned when a change is not reverted
+var errUndo = errors.New("change not reverted")
+
+var (
+	// activeChange records the files written by the running command, it is created on the first write
+	activeChange *changes.Change
+	// undoList lists the changes instead of reverting one
+	undoList bool
+	// undoDestroy destroys the resources introduced by the change without asking
+	undoDestroy bool
+	// undoForce reverts files edited since the change
+	undoForce bool
+	// undoStateRm removes the resources imported by the change from the state without asking
+	undoStateRm bool
+)
+
+// addUndo
+//
+//	@return *cobra.Command
+func addUndo() *cobra.Command {
+	undoCmd := &cobra.Command{
+		Use:   "undo [change-id]",
+		Short: "Revert the files written by the last run (or the given change): restore the overwritten files and delete the created ones",
+		Args:  cobra.MaximumNArgs(1),
+		// terraform is only needed to destroy or remove imported resources, it is created then
+		PersistentPreRunE: preRun,
+		RunE:              undoCommand,
+	}
+	undoCmd.Flags().BoolVar(&undoList, "list", false, "List the recorded changes instead of reverting one.")
+	undoCmd.Flags().BoolVar(&undoDestroy, "destroy", false, "Destroy the resources introduced by the change before reverting its files, without asking.")
+	undoCmd.Flags().BoolVar(&undoForce, "force", false, "Revert files even when they were edited after the change.")
+	undoCmd.Flags().BoolVar(&undoStateRm, "state-rm", false, "Remove the resources imported by the change from the state before reverting its files, without asking. They are not destroyed.")
+	return undoCmd
+}
+
+// undoCommand is a function that handles the "undo" command in the CLI
+//
+//	@param _
+//	@param args
+//	@return error
+func undoCommand(_ *cobra.Command, args []string) error {
+	if undoList {
+		return listChanges()
+	}
+	id := changes.Last
+	if len(args) > 0 {
+		id = args[0]
+	}
+	return undo(id)
+}
+
+// undo reverts a change, offering first to destroy the resources its files introduced
+//
+//	@param id
+//	@return error
+func undo(id string) error {
+	c, err := changes.Load(changesDir(), id)
+	if err != nil {
+		return err
+	}
+	if err = c.Check(undoForce); err != nil {
+		return err
+	}
+	log.Printf("↩️ Undoing change %s (%s, %s)\n", c.ID, c.Command, c.Created.Format(time.DateTime))
+	for _, f := range c.Files {
+		if f.Created {
+			log.Printf("  delete  %s\n", f.Path)
+		} else {
+			log.Printf("  restore %s\n", f.Path)
+		}
+	}
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+	targets, err := introducedAddresses(c)
+	if err != nil {
+		return err
+	}
+	if len(targets) > 0 && confirmDestroy(targets) {
+		if err = createOps(); err != nil {
+			return err
+		}
+		err = ops.Destroy(ctx, targets)
+		if auditErr := auditResult(audit.EventDestroy, err); auditErr != nil && err == nil {
+			err = auditErr
+		}
+		if err != nil {
+			return fmt.Errorf("error destroying the resources of change %s, its files were not reverted: %w", c.ID, err)
+		}
+	}
+	if err = removeImported(ctx, c); err != nil {
+		return err
+	}
+
+	if err = c.Revert(undoForce); err != nil {
+		return err
+	}
+	log.Printf("✅ Reverted %d file(s) of change %s\n", len(c.Files), c.ID)
+	return nil
+}
+
+// introducedAddresses returns the resources and modules declared by the .tf files of the change
+// that were not declared by the files before it, except the resources it imported, which existed before
+//
+//	@param c
+//	@return []string
+//	@return error
+func introducedAddresses(c *changes.Change) ([]string, error) {
+	var introduced []string
+	for _, f := range c.Files {
+		if filepath.Ext(f.Path) != ".tf" {
+			continue
+		}
+		src, err := os.ReadFile(f.Path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s:%w", f.Path, err)
+		}
+		after, err := terraform.ManagedAddresses(f.Path, src)
+		if err != nil {
+			return nil, err
+		}
+		before, err := c.Before(f)
+		if err != nil {
+			return nil, err
+		}
+		existing, err := terraform.ManagedAddresses(f.Path, before)
+		if err != nil {
+			return nil, err
+		}
+		for _, address := range after {
+			if !slices.Contains(existing, address) && !slices.Contains(c.Imported, address) && !slices.Contains(introduced, address) {
+				introduced = append(introduced, address)
+			}
+		}
+	}
+	return introduced, nil
+}
+
+// removeImported removes the resources imported by the change from the state once confirmed, otherwise
+// the next apply would destroy them when the files declaring them are reverted
+//
+//	@param ctx
+//	@param c
+//	@return error
+func removeImported(ctx context.Context, c *changes.Change) error {
+	if len(c.Imported) == 0 {
+		return nil
+	}
+	if err := createOps(); err != nil {
+		return err
+	}
+	state, err := ops.State(ctx)
+	if err != nil {
+		return err
+	}
+	inState := terraform.StateAddresses(state)
+	var imported []string
+	for _, address := range c.Imported {
+		if slices.Contains(inState, address) {
+			imported = append(imported, address)
+		}
+	}
+	if len(imported) == 0 {
+		return nil
+	}
+	if !confirmStateRm(imported) {
+		return errors.Wrapf(errUndo, "the files of change %s were not reverted, the next apply would destroy the imported %s", c.ID, strings.Join(imported, ", "))
+	}
+	if err = ops.StateRm(ctx, imported); err != nil {
+		return fmt.Errorf("error removing the imported resources of change %s from the state, its files were not reverted: %w", c.ID, err)
+	}
+	log.Printf("✂️ Removed %s from the state, the resources themselves are left as they are\n", strings.Join(imported, ", "))
+	return nil
+}
+
+// confirmStateRm asks whether to remove the imported resources from the state, --state-rm answers yes
+// and --require-confirmation=false without --state-rm answers no
+//
+//	@param imported
+//	@return bool
+func confirmStateRm(imported []string) bool {
+	log.Printf("📥 The change imported %s\n", strings.Join(imported, ", "))
+	if undoStateRm {
+		return true
+	}
+	if !*requireConfirmation {
+		return false
+	}
+	prompt := promptui.Prompt{
+		Label:     fmt.Sprintf("Remove these %d resource(s) from the state, without destroying them, before reverting the files", len(imported)),
+		IsConfirm: true,
+	}
+	// a "no" answer is returned as an error by promptui
+	_, err := prompt.Run()
+	return err == nil
+}
+
+// confirmDestroy asks whether to destroy the resources, --destroy answers yes and
+// --require-confirmation=false without --destroy answers no
+//
+//	@param targets
+//	@return bool
+func confirmDestroy(targets []string) bool {
+	log.Printf("🧨 The change introduced %s\n", strings.Join(targets, ", "))
+	if undoDestroy {
+		return true
+	}
+	if !*requireConfirmation {
+		return false
+	}
+	prompt := promptui.Prompt{
+		Label:     fmt.Sprintf("Destroy these %d resource(s) before reverting the files", len(targets)),
+		IsConfirm: true,
+	}
+	// a "no" answer is returned as an error by promptui
+	_, err := prompt.Run()
+	return err == nil
+}
+
+// listChanges prints the recorded changes, the most recent first
+//
+//	@return error
+func listChanges() error {
+	list, err := changes.List(changesDir())
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		fmt.Println("No changes.")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCOMMAND\tCREATED\tFILES\tUNDONE")
+	for _, c := range list {
+		names := make([]string, 0, len(c.Files))
+		for _, f := range c.Files {
+			names = append(names, filepath.Base(f.Path))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\n", c.ID, c.Command, c.Created.Format(time.DateTime), strings.Join(names, ","), c.Undone)
+	}
+	return w.Flush()
+}
+
+// recordImports records the addresses imported by the running command in its change
+//
+//	@param addresses
+//	@return error
+func recordImports(addresses []string) error {
+	if activeChange == nil {
+		activeChange = changes.New(changesDir(), auditCommand)
+	}
+	if err := activeChange.RecordImports(addresses); err != nil {
+		return fmt.Errorf("error recording imports for undo: %w", err)
+	}
+	return nil
+}
+
+// recordChange records a file about to be written in the change of the running command
+//
+//	@param path
+//	@param contents
+//	@return error
+func recordChange(path string, contents []byte) error {
+	if activeChange == nil {
+		activeChange = changes.New(changesDir(), auditCommand)
+	}
+	if err := activeChange.Record(path, contents); err != nil {
+		return fmt.Errorf("error recording change for undo: %w", err)
+	}
+	return nil
+}
+
+// changesDir returns the directory of the change manifests in the working dir
+//
+//	@return string
+func changesDir() string {
+	return filepath.Join(*workingDir, changes.DefaultDir)
+}
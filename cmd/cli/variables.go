@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"pradytpk/go-terraform-ai/pkg/terraform"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/manifoldco/promptui"
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Name of the variable file terraform loads automatically
+const tfvarsFile = "terraform.tfvars"
+
+// Error for variables without a value
+var errVariables = errors.New("missing variable values")
+
+// promptVariables asks for the values of the required variables that are not set
+// by --var, --var-file, an auto loaded tfvars file or a TF_VAR_ environment variable
+//
+//	@return error
+func promptVariables() error {
+	required, err := terraform.RequiredVariables(*workingDir)
+	if err != nil {
+		return fmt.Errorf("error reading variables:%w", err)
+	}
+	provided, err := providedVariables()
+	if err != nil {
+		return err
+	}
+	var missing []terraform.Variable
+	for _, v := range required {
+		if !provided[v.Name] {
+			missing = append(missing, v)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if !*requireConfirmation {
+		names := make([]string, 0, len(missing))
+		for _, v := range missing {
+			names = append(names, v.Name)
+		}
+		return errors.Wrapf(errVariables, "set %s with --var, --var-file or TF_VAR_<name>", strings.Join(names, ", "))
+	}
+
+	values := map[string]string{}
+	for _, v := range missing {
+		label := v.Name
+		if v.Description != "" {
+			label = fmt.Sprintf("%s (%s)", v.Name, v.Description)
+		}
+		prompt := promptui.Prompt{Label: label}
+		if v.Sensitive {
+			prompt.Mask = '*'
+		}
+		value, err := prompt.Run()
+		if err != nil {
+			return fmt.Errorf("error reading variable %s:%w", v.Name, err)
+		}
+		ops.SetVar(v.Name, value)
+		values[v.Name] = value
+	}
+	return offerTfvars(missing, values)
+}
+
+// providedVariables returns the names of the variables that already have a value
+//
+//	@return map[string]bool
+//	@return error
+func providedVariables() (map[string]bool, error) {
+	provided := map[string]bool{}
+	for _, assignment := range *tfVars {
+		name, _, _ := strings.Cut(assignment, "=")
+		provided[strings.TrimSpace(name)] = true
+	}
+	files := append(terraform.AutoVarFiles(*workingDir), *tfVarFiles...)
+	for _, file := range files {
+		names, err := terraform.VarFileNames(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			provided[name] = true
+		}
+	}
+	for _, kv := range os.Environ() {
+		if name, found := strings.CutPrefix(kv, "TF_VAR_"); found {
+			name, _, _ = strings.Cut(name, "=")
+			provided[name] = true
+		}
+	}
+	return provided, nil
+}
+
+// offerTfvars offers to save the non sensitive values into terraform.tfvars
+//
+//	@param variables
+//	@param values
+//	@return error
+func offerTfvars(variables []terraform.Variable, values map[string]string) error {
+	prompt := promptui.Prompt{
+		Label:     fmt.Sprintf("Save the values to %s (sensitive values are skipped)", tfvarsFile),
+		IsConfirm: true,
+	}
+	if _, err := prompt.Run(); err != nil {
+		// a "no" answer is returned as an error by promptui
+		return nil
+	}
+	path := filepath.Join(*workingDir, tfvarsFile)
+	src, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error reading %s:%w", tfvarsFile, err)
+	}
+	file, diags := hclwrite.ParseConfig(src, path, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return fmt.Errorf("error parsing %s:%w", tfvarsFile, diags)
+	}
+	for _, v := range variables {
+		if v.Sensitive {
+			continue
+		}
+		setTfvar(file.Body(), v, values[v.Name])
+	}
+	if err = os.WriteFile(path, file.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("error writing %s:%w", tfvarsFile, err)
+	}
+	log.Printf("💾 Saved variable values to %s\n", path)
+	return nil
+}
+
+// setTfvar writes the value as a string, or as an expression when the variable is not a string
+//
+//	@param body
+//	@param v
+//	@param value
+func setTfvar(body *hclwrite.Body, v terraform.Variable, value string) {
+	if v.Type != "" && v.Type != "string" {
+		expr, diags := hclwrite.ParseConfig([]byte(v.Name+" = "+value+"\n"), "", hcl.Pos{Line: 1, Column: 1})
+		if attr := expr.Body().GetAttribute(v.Name); !diags.HasErrors() && attr != nil {
+			body.SetAttributeRaw(v.Name, attr.Expr().BuildTokens(nil))
+			return
+		}
+	}
+	body.SetAttributeValue(v.Name, cty.StringVal(value))
+}
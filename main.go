@@ -10,12 +10,9 @@ import (
 func main() {
 	workingDir, err := utils.CurrenDir()
 	if err != nil {
-		log.Fatalf("failed to get the current dir:%s\n")
-	}
-	execDir, err := utils.TerraformPath()
-	if err != nil {
-		log.Fatalf("failed to get the exec dir:%s\n")
-
+		log.Fatalf("failed to get the current dir:%s\n", err)
 	}
+	// terraform is only required by the commands running it, which report it missing
+	execDir, _ := utils.TerraformPath()
 	cli.InitAndExecute(workingDir, execDir)
 }
@@ -0,0 +1,238 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultPath is the path, relative to the working dir, of the audit log
+const DefaultPath = ".terraform-assistant/audit.jsonl"
+
+// Events of the audit log
+const (
+	EventPrompt  = "prompt"
+	EventFile    = "file"
+	EventPlan    = "plan"
+	EventApply   = "apply"
+	EventInit    = "init"
+	EventDestroy = "destroy"
+)
+
+// Results of the init, apply and destroy events
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// maxLine is the longest entry the log can hold
+const maxLine = 16 * 1024 * 1024
+
+var errTampered = errors.New("audit log has been tampered with")
+
+// Entry is a line of the audit log. Hash is the SHA-256 of the previous hash and the entry
+// encoded without its hash, so changing, removing or reordering entries breaks the chain.
+type Entry struct {
+	Seq            int       `json:"seq"`
+	Time           time.Time `json:"time"`
+	User           string    `json:"user"`
+	Command        string    `json:"command,omitempty"`
+	Model          string    `json:"model,omitempty"`
+	Deployment     string    `json:"deployment,omitempty"`
+	Event          string    `json:"event"`
+	PromptSHA256   string    `json:"prompt_sha256,omitempty"`
+	Prompt         string    `json:"prompt,omitempty"`
+	ResponseSHA256 string    `json:"response_sha256,omitempty"`
+	Response       string    `json:"response,omitempty"`
+	File           string    `json:"file,omitempty"`
+	FileSHA256     string    `json:"file_sha256,omitempty"`
+	Plan           string    `json:"plan,omitempty"`
+	Result         string    `json:"result,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	PrevHash       string    `json:"prev_hash"`
+	Hash           string    `json:"hash"`
+}
+
+// Logger appends hash chained entries to an audit log
+type Logger struct {
+	mu       sync.Mutex
+	path     string
+	user     string
+	seq      int
+	lastHash string
+}
+
+// Open opens the audit log at path, creating it when needed, and verifies its chain so new
+// entries are never appended to a tampered log
+//
+//	@param path
+//	@return *Logger
+//	@return error
+func Open(path string) (*Logger, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("error creating audit dir:%w", err)
+	}
+	l := &Logger{path: path, user: currentUser()}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening audit log:%w", err)
+	}
+	defer f.Close()
+	last, err := verify(f, nil)
+	if err != nil {
+		return nil, err
+	}
+	l.seq, l.lastHash = last.Seq, last.Hash
+	return l, nil
+}
+
+// Log completes the entry with its sequence number, time, user and hashes and appends it
+//
+//	@receiver l
+//	@param e
+//	@return error
+func (l *Logger) Log(e Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e.Seq = l.seq + 1
+	e.Time = time.Now().UTC()
+	e.User = l.user
+	e.PrevHash = l.lastHash
+	hash, err := e.hash()
+	if err != nil {
+		return err
+	}
+	e.Hash = hash
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("error encoding audit entry:%w", err)
+	}
+
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("error opening audit log:%w", err)
+	}
+	defer f.Close()
+	if _, err = f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("error writing audit log:%w", err)
+	}
+	l.seq, l.lastHash = e.Seq, e.Hash
+	return nil
+}
+
+// Verify checks the chain of the audit log at path and returns its last entry, the head of the chain.
+// The chain alone cannot tell a truncated or fully rewritten log from a genuine one, so head, a hash
+// printed by an earlier verify and kept outside of the log, must be an entry of the chain when given.
+//
+//	@param path
+//	@param head
+//	@return Entry
+//	@return error
+func Verify(path string, head string) (Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Entry{}, fmt.Errorf("error opening audit log:%w", err)
+	}
+	defer f.Close()
+	found := head == ""
+	last, err := verify(f, func(e Entry) {
+		found = found || e.Hash == head
+	})
+	if err != nil {
+		return last, err
+	}
+	if !found {
+		return last, errors.Wrapf(errTampered, "the known head %s is not in the chain, the log was truncated or rewritten", head)
+	}
+	return last, nil
+}
+
+// SHA256 returns the hex encoded SHA-256 of the text
+//
+//	@param text
+//	@return string
+func SHA256(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// verify reads the entries and checks their sequence numbers and hash chain, it returns the last entry
+//
+//	@param r
+//	@param visit is called with every verified entry, it can be nil
+//	@return Entry
+//	@return error
+func verify(r io.Reader, visit func(e Entry)) (Entry, error) {
+	var last Entry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLine)
+	for line := 1; scanner.Scan(); line++ {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return last, errors.Wrapf(errTampered, "line %d is not a valid entry: %s", line, err)
+		}
+		if e.Seq != last.Seq+1 {
+			return last, errors.Wrapf(errTampered, "line %d has sequence number %d, expected %d", line, e.Seq, last.Seq+1)
+		}
+		if e.PrevHash != last.Hash {
+			return last, errors.Wrapf(errTampered, "line %d does not chain to the previous entry", line)
+		}
+		hash, err := e.hash()
+		if err != nil {
+			return last, err
+		}
+		if hash != e.Hash {
+			return last, errors.Wrapf(errTampered, "line %d was modified, its hash does not match", line)
+		}
+		if visit != nil {
+			visit(e)
+		}
+		last = e
+	}
+	if err := scanner.Err(); err != nil {
+		return last, fmt.Errorf("error reading audit log:%w", err)
+	}
+	return last, nil
+}
+
+// hash returns the SHA-256 of the previous hash and the entry encoded without its hash
+//
+//	@receiver e
+//	@return string
+//	@return error
+func (e Entry) hash() (string, error) {
+	e.Hash = ""
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", fmt.Errorf("error encoding audit entry:%w", err)
+	}
+	return SHA256(e.PrevHash + string(data)), nil
+}
+
+// currentUser returns the name of the OS user
+//
+//	@return string
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	for _, name := range []string{"USER", "USERNAME"} {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return "unknown"
+}
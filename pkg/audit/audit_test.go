@@ -0,0 +1,202 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// writeLog logs the entries into a new audit log and returns its path and the hash of every entry
+func writeLog(t *testing.T, entries ...Entry) (string, []string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audit", "audit.jsonl")
+	writeAt(t, path, entries...)
+	hashes := make([]string, len(entries))
+	if _, err := verify(mustOpen(t, path), func(e Entry) { hashes[e.Seq-1] = e.Hash }); err != nil {
+		t.Fatalf("verify() error = %v", err)
+	}
+	return path, hashes
+}
+
+// mustOpen opens the file, it is closed at the end of the test
+func mustOpen(t *testing.T, path string) *os.File {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func TestVerify(t *testing.T) {
+	entries := []Entry{
+		{Command: "run", Deployment: "prod-chat", Model: "gpt-4o", Event: EventPrompt, PromptSHA256: SHA256("prompt"), ResponseSHA256: SHA256("response")},
+		{Command: "run", Event: EventFile, File: "main.tf", FileSHA256: SHA256("content")},
+		{Command: "run", Event: EventApply, Result: ResultSuccess},
+	}
+	tests := []struct {
+		name string
+		// tamper changes the lines of the log
+		tamper func(lines []string) []string
+		// head is the index of the known head in the original log, -1 for none
+		head    int
+		wantSeq int
+		wantErr bool
+	}{
+		{
+			name:    "untouched",
+			tamper:  func(lines []string) []string { return lines },
+			head:    -1,
+			wantSeq: 3,
+		},
+		{
+			name:    "untouched with the last head",
+			tamper:  func(lines []string) []string { return lines },
+			head:    2,
+			wantSeq: 3,
+		},
+		{
+			name:    "appended after an earlier head",
+			tamper:  func(lines []string) []string { return lines },
+			head:    0,
+			wantSeq: 3,
+		},
+		{
+			name: "modified entry",
+			tamper: func(lines []string) []string {
+				lines[1] = strings.Replace(lines[1], "main.tf", "other.tf", 1)
+				return lines
+			},
+			head:    -1,
+			wantErr: true,
+		},
+		{
+			name: "modified response hash",
+			tamper: func(lines []string) []string {
+				lines[0] = strings.Replace(lines[0], SHA256("response"), SHA256("other"), 1)
+				return lines
+			},
+			head:    -1,
+			wantErr: true,
+		},
+		{
+			name:    "removed entry",
+			tamper:  func(lines []string) []string { return append(lines[:1:1], lines[2:]...) },
+			head:    -1,
+			wantErr: true,
+		},
+		{
+			name:    "reordered entries",
+			tamper:  func(lines []string) []string { return []string{lines[1], lines[0], lines[2]} },
+			head:    -1,
+			wantErr: true,
+		},
+		{
+			name:    "truncated without a head",
+			tamper:  func(lines []string) []string { return lines[:2] },
+			head:    -1,
+			wantSeq: 2,
+		},
+		{
+			name:    "truncated before the head",
+			tamper:  func(lines []string) []string { return lines[:2] },
+			head:    2,
+			wantErr: true,
+		},
+		{
+			name:    "emptied with a head",
+			tamper:  func([]string) []string { return nil },
+			head:    0,
+			wantErr: true,
+		},
+		{
+			name:    "invalid line",
+			tamper:  func(lines []string) []string { return append(lines, "{not json") },
+			head:    -1,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, hashes := writeLog(t, entries...)
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := tt.tamper(strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"))
+			content := strings.Join(lines, "\n")
+			if content != "" {
+				content += "\n"
+			}
+			if err = os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			head := ""
+			if tt.head >= 0 {
+				head = hashes[tt.head]
+			}
+			last, err := Verify(path, head)
+			if tt.wantErr {
+				if !errors.Is(err, errTampered) {
+					t.Fatalf("Verify() error = %v, want %v", err, errTampered)
+				}
+				// a log truncated before the head still has a valid chain, only the head tells
+				if _, err = Open(path); tt.head < 0 && !errors.Is(err, errTampered) {
+					t.Errorf("Open() of a tampered log error = %v, want %v", err, errTampered)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if last.Seq != tt.wantSeq {
+				t.Errorf("Verify() head seq = %d, want %d", last.Seq, tt.wantSeq)
+			}
+		})
+	}
+}
+
+func TestRewrittenLog(t *testing.T) {
+	path, hashes := writeLog(t, Entry{Event: EventPrompt, Prompt: "create a bucket"})
+	// a rewritten log has a valid chain of its own, only the known head tells it apart
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	writeAt(t, path, Entry{Event: EventPrompt, Prompt: "something else"})
+	if _, err := Verify(path, ""); err != nil {
+		t.Fatalf("Verify() without a head error = %v", err)
+	}
+	if _, err := Verify(path, hashes[0]); !errors.Is(err, errTampered) {
+		t.Errorf("Verify() with the known head error = %v, want %v", err, errTampered)
+	}
+}
+
+func TestOpenContinuesChain(t *testing.T) {
+	path, hashes := writeLog(t, Entry{Event: EventPrompt}, Entry{Event: EventPlan})
+	writeAt(t, path, Entry{Event: EventApply, Result: ResultFailure, Error: "boom"})
+	last, err := Verify(path, hashes[1])
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if last.Seq != 3 || last.PrevHash != hashes[1] || last.User == "" {
+		t.Errorf("Verify() head = %+v, want seq 3 chained to %s with a user", last, hashes[1])
+	}
+}
+
+// writeAt opens the audit log at path and logs the entries
+func writeAt(t *testing.T, path string, entries ...Entry) {
+	t.Helper()
+	l, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	for _, e := range entries {
+		if err = l.Log(e); err != nil {
+			t.Fatalf("Log() error = %v", err)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package changes
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultDir is the directory, relative to the working dir, holding a directory per change with
+// its manifest and the backups of the files it overwrote
+const DefaultDir = ".terraform-assistant/changes"
+
+// Last is the most recent change that was not undone yet
+const Last = "last"
+
+const manifestFile = "manifest.json"
+
+var (
+	errChange = errors.New("change not found")
+	errUndone = errors.New("change already undone")
+	// errModified is returned when a file was edited after the change wrote it
+	errModified = errors.New("file modified since the change")
+)
+
+// File is a file written by a change
+type File struct {
+	// Path is the absolute path of the file
+	Path string `json:"path"`
+	// Created is true when the file did not exist before the change
+	Created bool `json:"created"`
+	// Backup is the name, in the change dir, of the copy of the file before the change
+	Backup string      `json:"backup,omitempty"`
+	Mode   os.FileMode `json:"mode,omitempty"`
+	// SHA256 is the hash of the content last written by the change
+	SHA256 string `json:"sha256"`
+}
+
+// Change is the manifest of the files written by a run of the assistant, with what is needed to revert them
+type Change struct {
+	ID      string    `json:"id"`
+	Command string    `json:"command"`
+	Created time.Time `json:"created"`
+	Files   []File    `json:"files"`
+	// Imported are the addresses of the existing resources the change imported, the resource
+	// blocks of its files for them declare infrastructure that existed before the change
+	Imported []string `json:"imported,omitempty"`
+	Undone   bool     `json:"undone,omitempty"`
+
+	dir string
+}
+
+// New creates a change in dir, nothing is written before the first file is recorded
+//
+//	@param dir
+//	@param command
+//	@return *Change
+func New(dir string, command string) *Change {
+	now := time.Now()
+	suffix := make([]byte, 3)
+	_, _ = rand.Read(suffix)
+	id := fmt.Sprintf("%s-%s", now.Format("20060102-150405"), hex.EncodeToString(suffix))
+	return &Change{ID: id, Command: command, Created: now, dir: filepath.Join(dir, id)}
+}
+
+// Record is called before contents are written to path, it backs up the file the first time
+// the change writes it and saves the manifest
+//
+//	@receiver c
+//	@param path
+//	@param contents
+//	@return error
+func (c *Change) Record(path string, contents []byte) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("error resolving %s:%w", path, err)
+	}
+	sum := sha256.Sum256(contents)
+	for i := range c.Files {
+		if c.Files[i].Path == abs {
+			c.Files[i].SHA256 = hex.EncodeToString(sum[:])
+			return c.save()
+		}
+	}
+	if err = os.MkdirAll(c.dir, 0o700); err != nil {
+		return fmt.Errorf("error creating change dir:%w", err)
+	}
+	f := File{Path: abs, SHA256: hex.EncodeToString(sum[:])}
+	info, err := os.Stat(abs)
+	switch {
+	case os.IsNotExist(err):
+		f.Created = true
+	case err != nil:
+		return fmt.Errorf("error reading %s:%w", path, err)
+	default:
+		src, err := os.ReadFile(abs)
+		if err != nil {
+			return fmt.Errorf("error reading %s:%w", path, err)
+		}
+		f.Backup = fmt.Sprintf("%d-%s", len(c.Files), filepath.Base(abs))
+		f.Mode = info.Mode().Perm()
+		if err = os.WriteFile(filepath.Join(c.dir, f.Backup), src, 0o600); err != nil {
+			return fmt.Errorf("error backing up %s:%w", path, err)
+		}
+	}
+	c.Files = append(c.Files, f)
+	return c.save()
+}
+
+// RecordImports records the addresses of the resources imported by the change and saves the manifest
+//
+//	@receiver c
+//	@param addresses
+//	@return error
+func (c *Change) RecordImports(addresses []string) error {
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return fmt.Errorf("error creating change dir:%w", err)
+	}
+	for _, address := range addresses {
+		if !slices.Contains(c.Imported, address) {
+			c.Imported = append(c.Imported, address)
+		}
+	}
+	return c.save()
+}
+
+// Before returns the content of the file before the change, nil for a created file
+//
+//	@receiver c
+//	@param f
+//	@return []byte
+//	@return error
+func (c *Change) Before(f File) ([]byte, error) {
+	if f.Created {
+		return nil, nil
+	}
+	src, err := os.ReadFile(filepath.Join(c.dir, f.Backup))
+	if err != nil {
+		return nil, fmt.Errorf("error reading backup of %s:%w", f.Path, err)
+	}
+	return src, nil
+}
+
+// Modified returns the files edited since the change last wrote them
+//
+//	@receiver c
+//	@return []string
+//	@return error
+func (c *Change) Modified() ([]string, error) {
+	var modified []string
+	for _, f := range c.Files {
+		src, err := os.ReadFile(f.Path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s:%w", f.Path, err)
+		}
+		if sum := sha256.Sum256(src); hex.EncodeToString(sum[:]) != f.SHA256 {
+			modified = append(modified, f.Path)
+		}
+	}
+	return modified, nil
+}
+
+// Check returns an error when the change cannot be reverted: it was already undone, or without
+// force, files were edited since the change wrote them
+//
+//	@receiver c
+//	@param force
+//	@return error
+func (c *Change) Check(force bool) error {
+	if c.Undone {
+		return errors.Wrapf(errUndone, "change %s", c.ID)
+	}
+	if force {
+		return nil
+	}
+	modified, err := c.Modified()
+	if err != nil {
+		return err
+	}
+	if len(modified) > 0 {
+		return errors.Wrapf(errModified, "%s, revert anyway with --force", strings.Join(modified, ", "))
+	}
+	return nil
+}
+
+// Revert restores the files overwritten by the change and deletes the ones it created. Files edited
+// since the change are only reverted with force, so later manual work is not lost silently.
+//
+//	@receiver c
+//	@param force
+//	@return error
+func (c *Change) Revert(force bool) error {
+	if err := c.Check(force); err != nil {
+		return err
+	}
+	for i := len(c.Files) - 1; i >= 0; i-- {
+		f := c.Files[i]
+		if f.Created {
+			if err := os.Remove(f.Path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("error deleting %s:%w", f.Path, err)
+			}
+			continue
+		}
+		src, err := c.Before(f)
+		if err != nil {
+			return err
+		}
+		if err = os.WriteFile(f.Path, src, f.Mode); err != nil {
+			return fmt.Errorf("error restoring %s:%w", f.Path, err)
+		}
+	}
+	c.Undone = true
+	return c.save()
+}
+
+// save writes the manifest into the change dir
+//
+//	@receiver c
+//	@return error
+func (c *Change) save() error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding change:%w", err)
+	}
+	if err = os.WriteFile(filepath.Join(c.dir, manifestFile), data, 0o600); err != nil {
+		return fmt.Errorf("error writing change:%w", err)
+	}
+	return nil
+}
+
+// Load reads the change with the id from dir, Last loads the most recent change not undone yet
+//
+//	@param dir
+//	@param id
+//	@return *Change
+//	@return error
+func Load(dir string, id string) (*Change, error) {
+	if id == Last {
+		changes, err := List(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range changes {
+			if !c.Undone {
+				return c, nil
+			}
+		}
+		return nil, errors.Wrapf(errChange, "nothing to undo in %s", dir)
+	}
+	changeDir := filepath.Join(dir, filepath.Base(id))
+	data, err := os.ReadFile(filepath.Join(changeDir, manifestFile))
+	if os.IsNotExist(err) {
+		return nil, errors.Wrapf(errChange, "no change %q", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading change:%w", err)
+	}
+	c := &Change{dir: changeDir}
+	if err = json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("error decoding change %s:%w", id, err)
+	}
+	return c, nil
+}
+
+// List returns the changes in dir, the most recent first
+//
+//	@param dir
+//	@return []*Change
+//	@return error
+func List(dir string) ([]*Change, error) {
+	manifests, err := filepath.Glob(filepath.Join(dir, "*", manifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("error listing changes:%w", err)
+	}
+	changes := make([]*Change, 0, len(manifests))
+	for _, manifest := range manifests {
+		c, err := Load(dir, filepath.Base(filepath.Dir(manifest)))
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Created.After(changes[j].Created)
+	})
+	return changes, nil
+}
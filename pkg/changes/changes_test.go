@@ -0,0 +1,220 @@
+package changes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// readFile returns the content of path, "" when it does not exist
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return ""
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRecord(t *testing.T) {
+	tests := []struct {
+		name string
+		// before is the content of the file before the change, "" for a missing file
+		before      string
+		wantCreated bool
+		wantBackup  bool
+	}{
+		{name: "created file", wantCreated: true},
+		{name: "overwritten file", before: "old", wantBackup: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			work := t.TempDir()
+			path := filepath.Join(work, "main.tf")
+			if tt.before != "" {
+				if err := os.WriteFile(path, []byte(tt.before), 0o640); err != nil {
+					t.Fatal(err)
+				}
+			}
+			c := New(filepath.Join(work, DefaultDir), "run")
+			if err := c.Record(path, []byte("first")); err != nil {
+				t.Fatalf("Record() error = %v", err)
+			}
+			// a second write of the same file keeps the first backup
+			if err := c.Record(path, []byte("second")); err != nil {
+				t.Fatalf("Record() error = %v", err)
+			}
+			if len(c.Files) != 1 {
+				t.Fatalf("Files = %+v, want one file", c.Files)
+			}
+			f := c.Files[0]
+			if f.Created != tt.wantCreated || (f.Backup != "") != tt.wantBackup {
+				t.Errorf("File = %+v, want created %v, backup %v", f, tt.wantCreated, tt.wantBackup)
+			}
+			before, err := c.Before(f)
+			if err != nil {
+				t.Fatalf("Before() error = %v", err)
+			}
+			if string(before) != tt.before {
+				t.Errorf("Before() = %q, want %q", before, tt.before)
+			}
+			loaded, err := Load(filepath.Join(work, DefaultDir), c.ID)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if len(loaded.Files) != 1 || loaded.Files[0] != f {
+				t.Errorf("Load() files = %+v, want %+v", loaded.Files, c.Files)
+			}
+		})
+	}
+}
+
+func TestRevert(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		// after is written after the change, "" keeps what the change wrote
+		after   string
+		force   bool
+		undone  bool
+		want    string
+		wantErr error
+	}{
+		{name: "created file is deleted", want: ""},
+		{name: "overwritten file is restored", before: "old", want: "old"},
+		{name: "edited file is kept", before: "old", after: "edited", want: "edited", wantErr: errModified},
+		{name: "edited file with force", before: "old", after: "edited", force: true, want: "old"},
+		{name: "edited created file with force", after: "edited", force: true, want: ""},
+		{name: "already undone", before: "old", undone: true, want: "new", wantErr: errUndone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			work := t.TempDir()
+			path := filepath.Join(work, "main.tf")
+			if tt.before != "" {
+				if err := os.WriteFile(path, []byte(tt.before), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			c := New(filepath.Join(work, DefaultDir), "run")
+			if err := c.Record(path, []byte("new")); err != nil {
+				t.Fatalf("Record() error = %v", err)
+			}
+			content := "new"
+			if tt.after != "" {
+				content = tt.after
+			}
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			c.Undone = tt.undone
+
+			err := c.Revert(tt.force)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Revert() error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("Revert() error = %v", err)
+			}
+			if got := readFile(t, path); got != tt.want {
+				t.Errorf("file = %q, want %q", got, tt.want)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			loaded, err := Load(filepath.Join(work, DefaultDir), c.ID)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if !loaded.Undone {
+				t.Error("the reverted change is not saved as undone")
+			}
+		})
+	}
+}
+
+func TestRecordImports(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), DefaultDir)
+	c := New(dir, "import")
+	if err := c.RecordImports([]string{"aws_s3_bucket.logs", "aws_s3_bucket.data"}); err != nil {
+		t.Fatalf("RecordImports() error = %v", err)
+	}
+	if err := c.RecordImports([]string{"aws_s3_bucket.logs"}); err != nil {
+		t.Fatalf("RecordImports() error = %v", err)
+	}
+	loaded, err := Load(dir, c.ID)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(loaded.Imported) != 2 || loaded.Imported[0] != "aws_s3_bucket.logs" || loaded.Imported[1] != "aws_s3_bucket.data" {
+		t.Errorf("Imported = %v, want each address once", loaded.Imported)
+	}
+}
+
+func TestListAndLoadLast(t *testing.T) {
+	work := t.TempDir()
+	dir := filepath.Join(work, DefaultDir)
+	created := time.Now()
+	var ids []string
+	for i, undone := range []bool{false, false, true} {
+		c := New(dir, "run")
+		// the ids hold the second, the creation times order the changes
+		c.ID = c.ID + string(rune('a'+i))
+		c.dir = filepath.Join(dir, c.ID)
+		c.Created = created.Add(time.Duration(i) * time.Minute)
+		if err := c.Record(filepath.Join(work, "main.tf"), []byte("x")); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+		c.Undone = undone
+		if err := c.save(); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, c.ID)
+	}
+
+	list, err := List(dir)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(list) != 3 || list[0].ID != ids[2] || list[1].ID != ids[1] || list[2].ID != ids[0] {
+		t.Fatalf("List() = %+v, want the most recent first", list)
+	}
+
+	tests := []struct {
+		name    string
+		dir     string
+		id      string
+		want    string
+		wantErr error
+	}{
+		{name: "last skips the undone change", dir: dir, id: Last, want: ids[1]},
+		{name: "by id", dir: dir, id: ids[0], want: ids[0]},
+		{name: "unknown id", dir: dir, id: "missing", wantErr: errChange},
+		{name: "id leaving the dir", dir: dir, id: "../" + ids[0], want: ids[0]},
+		{name: "nothing to undo", dir: filepath.Join(work, "empty"), id: Last, wantErr: errChange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Load(tt.dir, tt.id)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if c.ID != tt.want {
+				t.Errorf("Load() = %s, want %s", c.ID, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectFile is the config file of a project, in its working dir
+const ProjectFile = ".terraform-assistant.yaml"
+
+// Sources of the settings
+const (
+	SourceUser    = "user"
+	SourceProject = "project"
+	SourceProfile = "profile"
+)
+
+var errProfile = errors.New("unknown profile")
+
+// File is a config file. Settings and profiles are keyed by the name of the flag they set,
+// e.g. azure-openai-endpoint or temperature
+type File struct {
+	// Profile is the profile used when none is given with --profile
+	Profile  string                       `yaml:"profile,omitempty"`
+	Settings map[string]string            `yaml:"settings,omitempty"`
+	Profiles map[string]map[string]string `yaml:"profiles,omitempty"`
+
+	path string
+}
+
+// Layer is a set of settings and where they come from
+type Layer struct {
+	Source   string
+	Settings map[string]string
+	// Project is set for the settings of the project file, which comes with the repository
+	Project bool
+}
+
+// UserPath returns the path of the user config file, $XDG_CONFIG_HOME/terraform-assistant/config.yaml
+// or ~/.config/terraform-assistant/config.yaml
+//
+//	@return string
+//	@return error
+func UserPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("error finding home dir:%w", err)
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "terraform-assistant", "config.yaml"), nil
+}
+
+// Load reads the config file at path, a missing file is an empty config
+//
+//	@param path
+//	@return *File
+//	@return error
+func Load(path string) (*File, error) {
+	f := &File{path: path}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading config %s:%w", path, err)
+	}
+	if err = yaml.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("error parsing config %s:%w", path, err)
+	}
+	return f, nil
+}
+
+// Path returns the path the file was loaded from
+//
+//	@receiver f
+//	@return string
+func (f *File) Path() string {
+	return f.path
+}
+
+// Set sets a setting, of the profile when profile is not empty
+//
+//	@receiver f
+//	@param profile
+//	@param key
+//	@param value
+func (f *File) Set(profile string, key string, value string) {
+	if profile == "" {
+		if f.Settings == nil {
+			f.Settings = map[string]string{}
+		}
+		f.Settings[key] = value
+		return
+	}
+	if f.Profiles == nil {
+		f.Profiles = map[string]map[string]string{}
+	}
+	if f.Profiles[profile] == nil {
+		f.Profiles[profile] = map[string]string{}
+	}
+	f.Profiles[profile][key] = value
+}
+
+// Save writes the file back to its path
+//
+//	@receiver f
+//	@return error
+func (f *File) Save() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o700); err != nil {
+		return fmt.Errorf("error creating config dir:%w", err)
+	}
+	data, err := yaml.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("error encoding config:%w", err)
+	}
+	if err = os.WriteFile(f.path, data, 0o600); err != nil {
+		return fmt.Errorf("error writing config %s:%w", f.path, err)
+	}
+	return nil
+}
+
+// ActiveProfile returns the profile in use: the given one, else the default of the project, else the one of the user
+//
+//	@param profile
+//	@param user
+//	@param project
+//	@return string
+func ActiveProfile(profile string, user *File, project *File) string {
+	if profile != "" {
+		return profile
+	}
+	if project.Profile != "" {
+		return project.Profile
+	}
+	return user.Profile
+}
+
+// Profiles returns the names of the profiles defined in the user and project files
+//
+//	@param user
+//	@param project
+//	@return []string
+func Profiles(user *File, project *File) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, f := range []*File{user, project} {
+		for name := range f.Profiles {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve returns the layers of settings from the lowest to the highest precedence: the user file,
+// the project file and the profile, whose project definition overrides the user one
+//
+//	@param user
+//	@param project
+//	@param profile
+//	@return []Layer
+//	@return error
+func Resolve(user *File, project *File, profile string) ([]Layer, error) {
+	layers := []Layer{
+		{Source: SourceUser, Settings: user.Settings},
+		{Source: SourceProject, Settings: project.Settings, Project: true},
+	}
+	if profile == "" {
+		return layers, nil
+	}
+	userProfile, inUser := user.Profiles[profile]
+	projectProfile, inProject := project.Profiles[profile]
+	if !inUser && !inProject {
+		return nil, errors.Wrapf(errProfile, "%q is not defined in %s or %s", profile, user.path, project.path)
+	}
+	source := SourceProfile + " " + profile
+	return append(layers, Layer{Source: source, Settings: userProfile}, Layer{Source: source, Settings: projectProfile, Project: true}), nil
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestActiveProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		flag    string
+		user    string
+		project string
+		want    string
+	}{
+		{name: "none"},
+		{name: "user default", user: "azure", want: "azure"},
+		{name: "project default over user", user: "azure", project: "openai", want: "openai"},
+		{name: "flag over defaults", flag: "local", user: "azure", project: "openai", want: "local"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ActiveProfile(tt.flag, &File{Profile: tt.user}, &File{Profile: tt.project})
+			if got != tt.want {
+				t.Errorf("ActiveProfile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	user := &File{
+		path:     "user.yaml",
+		Settings: map[string]string{"temperature": "0.1"},
+		Profiles: map[string]map[string]string{"azure": {"model": "gpt-4"}, "mine": {"model": "gpt-4o"}},
+	}
+	project := &File{
+		path:     "project.yaml",
+		Settings: map[string]string{"temperature": "0.2"},
+		Profiles: map[string]map[string]string{"azure": {"model": "gpt-4o-mini"}, "ci": {"backend": "s3"}},
+	}
+	tests := []struct {
+		name    string
+		profile string
+		want    []Layer
+		wantErr error
+	}{
+		{
+			name: "no profile",
+			want: []Layer{
+				{Source: SourceUser, Settings: user.Settings},
+				{Source: SourceProject, Settings: project.Settings, Project: true},
+			},
+		},
+		{
+			name:    "profile in both files, the project one last",
+			profile: "azure",
+			want: []Layer{
+				{Source: SourceUser, Settings: user.Settings},
+				{Source: SourceProject, Settings: project.Settings, Project: true},
+				{Source: "profile azure", Settings: user.Profiles["azure"]},
+				{Source: "profile azure", Settings: project.Profiles["azure"], Project: true},
+			},
+		},
+		{
+			name:    "profile of the user file",
+			profile: "mine",
+			want: []Layer{
+				{Source: SourceUser, Settings: user.Settings},
+				{Source: SourceProject, Settings: project.Settings, Project: true},
+				{Source: "profile mine", Settings: user.Profiles["mine"]},
+				{Source: "profile mine", Project: true},
+			},
+		},
+		{
+			name:    "profile of the project file",
+			profile: "ci",
+			want: []Layer{
+				{Source: SourceUser, Settings: user.Settings},
+				{Source: SourceProject, Settings: project.Settings, Project: true},
+				{Source: "profile ci"},
+				{Source: "profile ci", Settings: project.Profiles["ci"], Project: true},
+			},
+		},
+		{
+			name:    "unknown profile",
+			profile: "missing",
+			wantErr: errProfile,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Resolve(user, project, tt.profile)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Resolve() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resolve() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	user := &File{Profiles: map[string]map[string]string{"b": {}, "a": {}}}
+	project := &File{Profiles: map[string]map[string]string{"a": {}, "c": {}}}
+	if got, want := Profiles(user, project), []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Profiles() = %v, want %v", got, want)
+	}
+}
+
+func TestLoadSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dir", "config.yaml")
+	f, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() of a missing file error = %v", err)
+	}
+	f.Profile = "azure"
+	f.Set("", "temperature", "0.2")
+	f.Set("azure", "model", "gpt-4o")
+	if err = f.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, f) {
+		t.Errorf("Load() = %+v, want %+v", got, f)
+	}
+}
@@ -0,0 +1,121 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/zalando/go-keyring"
+)
+
+// service is the name the keys are stored under in the OS keyring
+const service = "terraform-assistant"
+
+var (
+	// errInsecureFile is returned for key files readable by other users
+	errInsecureFile = errors.New("key file is accessible by other users")
+	// errHelper is returned when the credential helper fails or prints no key
+	errHelper = errors.New("credential helper failed")
+)
+
+// FromCommand runs a credential helper command with the shell and returns the key it prints,
+// e.g. `op read op://vault/openai/key` or `pass show openai`
+//
+//	@param ctx
+//	@param command
+//	@return string
+//	@return error
+func FromCommand(ctx context.Context, command string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", errors.Wrapf(errHelper, "%s: %s %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+	key := strings.TrimSpace(string(out))
+	if key == "" {
+		return "", errors.Wrapf(errHelper, "%s printed no key", command)
+	}
+	return key, nil
+}
+
+// FromFile reads the key from a file, which must not be accessible by other users
+//
+//	@param path
+//	@return string
+//	@return error
+func FromFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading key file:%w", err)
+	}
+	// windows has no permission bits to check
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		return "", errors.Wrapf(errInsecureFile, "%s has mode %s, restrict it with chmod 600 %s", path, info.Mode().Perm(), path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading key file:%w", err)
+	}
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return "", fmt.Errorf("key file %s is empty", path)
+	}
+	return key, nil
+}
+
+// FromKeyring returns the key stored for the account in the OS keyring (Secret Service on Linux,
+// Keychain on macOS, Credential Manager on Windows), empty when none is stored
+//
+//	@param account
+//	@return string
+//	@return error
+func FromKeyring(account string) (string, error) {
+	key, err := keyring.Get(service, account)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading keyring:%w", err)
+	}
+	return key, nil
+}
+
+// Store saves the key for the account in the OS keyring
+//
+//	@param account
+//	@param key
+//	@return error
+func Store(account string, key string) error {
+	if err := keyring.Set(service, account, key); err != nil {
+		return fmt.Errorf("error writing keyring:%w", err)
+	}
+	return nil
+}
+
+// Delete removes the key of the account from the OS keyring, it reports false when there was none
+//
+//	@param account
+//	@return bool
+//	@return error
+func Delete(account string) (bool, error) {
+	err := keyring.Delete(service, account)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error writing keyring:%w", err)
+	}
+	return true, nil
+}
@@ -7,15 +7,23 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
+
+	"github.com/pkg/errors"
 )
 
+// DefaultAPIVersion is the api-version of the requests unless WithAPIVersion overrides it
+const DefaultAPIVersion = "2024-10-21"
+
 const (
-	defaultAPIVersion     = "2023-03-15-preview"
 	defaultUserAgent      = "kubectl-openai"
 	defaultTimeoutSeconds = 30
 )
 
+var errEndpoint = errors.New("invalid endpoint")
+
 // Client  is an api client to communicate with the openAI gtp3 apis
 type Client interface {
 	// ChatCompletion creates a completion with the Chat completion endpoint which
@@ -30,13 +38,15 @@ type Client interface {
 type client struct {
 	endpoint       string
 	apiKey         string
+	tokenProvider  TokenProvider
 	deploymentName string
 	apiVersion     string
 	userAgent      string
 	httpClient     *http.Client
 }
 
-// NewClient create a new gpt-3 client with the specified params
+// NewClient create a new gpt-3 client with the specified params, apiKey is ignored when
+// WithTokenProvider is given and the endpoint must be the https URL of the resource
 //
 //	@param endpoint
 //	@param apiKey
@@ -45,6 +55,11 @@ type client struct {
 //	@return Client
 //	@return error
 func NewClient(endpoint string, apiKey string, deploymentName string, options ...ClientOption) (Client, error) {
+	endpoint, err := validateEndpoint(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create a new HTTP client with a default timeout.
 	httpClient := &http.Client{
 		Timeout: defaultTimeoutSeconds * time.Second,
@@ -55,7 +70,7 @@ func NewClient(endpoint string, apiKey string, deploymentName string, options ..
 		endpoint:       endpoint,
 		apiKey:         apiKey,
 		deploymentName: deploymentName,
-		apiVersion:     defaultAPIVersion,
+		apiVersion:     DefaultAPIVersion,
 		userAgent:      defaultUserAgent,
 		httpClient:     httpClient,
 	}
@@ -70,6 +85,29 @@ func NewClient(endpoint string, apiKey string, deploymentName string, options ..
 	return c, nil
 }
 
+// validateEndpoint checks the endpoint is the URL of a resource, e.g. https://my-resource.openai.azure.com,
+// and removes its trailing slash. Plain http is only allowed for local stand-ins.
+//
+//	@param endpoint
+//	@return string
+//	@return error
+func validateEndpoint(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", errors.Wrapf(errEndpoint, "%q: %s", endpoint, err)
+	}
+	local := u.Hostname() == "localhost" || u.Hostname() == "127.0.0.1" || u.Hostname() == "::1"
+	switch {
+	case u.Host == "":
+		return "", errors.Wrapf(errEndpoint, "%q has no host, expected e.g. https://my-resource.openai.azure.com", endpoint)
+	case u.Scheme != "https" && !(u.Scheme == "http" && local):
+		return "", errors.Wrapf(errEndpoint, "%q must use https", endpoint)
+	case strings.Trim(u.Path, "/") != "" || u.RawQuery != "" || u.Fragment != "":
+		return "", errors.Wrapf(errEndpoint, "%q must not have a path or query, the deployment and api-version are set separately", endpoint)
+	}
+	return u.Scheme + "://" + u.Host, nil
+}
+
 // Completion sends a completion request to the OpenAI API and returns the completion response.
 //
 //	@receiver c
@@ -231,9 +269,17 @@ func (c *client) newRequest(ctx context.Context, method, path string, payload in
 		return nil, err
 	}
 
-	// Set the Content-type and api-key headers
+	// Set the Content-type header and authenticate with the bearer token of the token provider, or the api key
 	req.Header.Set("Content-type", "application/json")
-	req.Header.Set("api-key", c.apiKey)
+	if c.tokenProvider != nil {
+		token, err := c.tokenProvider.Token(ctx)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else {
+		req.Header.Set("api-key", c.apiKey)
+	}
 
 	return req, nil
 }
@@ -39,6 +39,52 @@ type ChatCompletionRequestMessage struct {
 
 	// Content is the content of the message
 	Content string `json:"content"`
+
+	// ToolCalls are the calls of tools requested by the model, in an assistant message
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+
+	// ToolCallID is the id of the call whose result is the content, in a tool message
+	ToolCallID string `json:"tool_call_id,omitempty"`
+}
+
+// Tool is a function the model may call, described by a JSON schema of its parameters.
+type Tool struct {
+	// Type is always "function"
+	Type     string       `json:"type"`
+	Function ToolFunction `json:"function"`
+}
+
+// ToolFunction is the name, description and parameters of a tool.
+type ToolFunction struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  ToolParameters `json:"parameters"`
+}
+
+// ToolParameters is the JSON schema of the parameters of a tool, an object with properties.
+type ToolParameters struct {
+	Type       string                  `json:"type"`
+	Properties map[string]ToolProperty `json:"properties"`
+	Required   []string                `json:"required"`
+}
+
+// ToolProperty is a parameter of a tool.
+type ToolProperty struct {
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+}
+
+// ToolCall is a call of a tool requested by the model, Arguments is a JSON object.
+type ToolCall struct {
+	ID       string           `json:"id"`
+	Type     string           `json:"type"`
+	Function ToolCallFunction `json:"function"`
+}
+
+// ToolCallFunction is the name of the called tool and its arguments.
+type ToolCallFunction struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
 }
 
 // ChatCompletionRequest is a request for the chat completion API.
@@ -78,6 +124,12 @@ type ChatCompletionRequest struct {
 
 	// Can be used to identify an end-user
 	User string `json:"user,omitempty"`
+
+	// Tools is a list of tools the model may call.
+	Tools []Tool `json:"tools,omitempty"`
+
+	// ToolChoice is "auto" to let the model call tools or "none" to make it answer, when tools are given.
+	ToolChoice string `json:"tool_choice,omitempty"`
 }
 
 // CompletionRequest is a request for the completions API.
@@ -144,8 +196,9 @@ type EmbeddingsRequest struct {
 
 // ChatCompletionResponseMessage is a message returned in the response to the Chat Completions API.
 type ChatCompletionResponseMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
 }
 
 // ChatCompletionResponseChoice is one of the choices returned in the response to the Chat Completions API.
@@ -0,0 +1,329 @@
+package gpt3
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// defaultAuthorityHost is the Entra ID authority, overridden by AZURE_AUTHORITY_HOST for sovereign clouds
+	defaultAuthorityHost = "https://login.microsoftonline.com"
+	// cognitiveServicesScope is the scope of the tokens accepted by Azure OpenAI
+	cognitiveServicesScope = "https://cognitiveservices.azure.com/.default"
+	// imdsEndpoint is the managed identity endpoint of Azure VMs
+	imdsEndpoint = "http://169.254.169.254/metadata/identity/oauth2/token"
+	// tokenRefreshMargin is how long before its expiry a cached token is renewed
+	tokenRefreshMargin = 5 * time.Minute
+	// jwtBearerAssertion is the client assertion type of federated tokens
+	jwtBearerAssertion = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+)
+
+var errToken = errors.New("error getting token")
+
+// TokenProvider returns the bearer token sent in the Authorization header instead of the api-key header
+type TokenProvider interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// AccessToken is a bearer token and its expiry
+type AccessToken struct {
+	Token     string
+	ExpiresOn time.Time
+}
+
+// tokenFunc requests a new token
+type tokenFunc func(ctx context.Context) (AccessToken, error)
+
+// cachedToken is a TokenProvider reusing its token until shortly before it expires
+type cachedToken struct {
+	mu    sync.Mutex
+	fetch tokenFunc
+	token AccessToken
+	now   func() time.Time
+}
+
+// TokenOption are options of the Entra ID token providers
+type TokenOption func(*tokenConfig)
+
+type tokenConfig struct {
+	authorityHost    string
+	identityEndpoint string
+	identityHeader   string
+	scope            string
+	httpClient       *http.Client
+}
+
+// WithAuthorityHost overrides the Entra ID authority, by default AZURE_AUTHORITY_HOST or login.microsoftonline.com
+//
+//	@param host
+//	@return TokenOption
+func WithAuthorityHost(host string) TokenOption {
+	return func(c *tokenConfig) {
+		c.authorityHost = host
+	}
+}
+
+// WithIdentityEndpoint overrides the managed identity endpoint, by default IDENTITY_ENDPOINT (App Service,
+// Functions, Container Apps) or the instance metadata service of VMs. header is sent as X-IDENTITY-HEADER.
+//
+//	@param endpoint
+//	@param header
+//	@return TokenOption
+func WithIdentityEndpoint(endpoint string, header string) TokenOption {
+	return func(c *tokenConfig) {
+		c.identityEndpoint, c.identityHeader = endpoint, header
+	}
+}
+
+// WithScope overrides the scope of the token, by default https://cognitiveservices.azure.com/.default
+//
+//	@param scope
+//	@return TokenOption
+func WithScope(scope string) TokenOption {
+	return func(c *tokenConfig) {
+		c.scope = scope
+	}
+}
+
+// WithTokenHTTPClient overrides the http.Client used to request tokens
+//
+//	@param httpClient
+//	@return TokenOption
+func WithTokenHTTPClient(httpClient *http.Client) TokenOption {
+	return func(c *tokenConfig) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithTokenProvider is a client option authenticating with Entra ID bearer tokens instead of an api key.
+//
+//	@param provider
+//	@return ClientOption
+func WithTokenProvider(provider TokenProvider) ClientOption {
+	return func(c *client) error {
+		c.tokenProvider = provider
+		return nil
+	}
+}
+
+// NewClientSecretCredential returns a provider of tokens of an app registration from the client credentials flow
+//
+//	@param tenantID
+//	@param clientID
+//	@param secret
+//	@param options
+//	@return TokenProvider
+func NewClientSecretCredential(tenantID string, clientID string, secret string, options ...TokenOption) TokenProvider {
+	config := newTokenConfig(options)
+	return newCachedToken(func(ctx context.Context) (AccessToken, error) {
+		return config.clientCredentials(ctx, tenantID, url.Values{
+			"client_id":     {clientID},
+			"client_secret": {secret},
+		})
+	})
+}
+
+// NewWorkloadIdentityCredential returns a provider of tokens exchanged for the federated token in tokenFile,
+// e.g. AZURE_FEDERATED_TOKEN_FILE of AKS workload identity. The file is read for every exchange as it is rotated.
+//
+//	@param tenantID
+//	@param clientID
+//	@param tokenFile
+//	@param options
+//	@return TokenProvider
+func NewWorkloadIdentityCredential(tenantID string, clientID string, tokenFile string, options ...TokenOption) TokenProvider {
+	config := newTokenConfig(options)
+	return newCachedToken(func(ctx context.Context) (AccessToken, error) {
+		assertion, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return AccessToken{}, errors.Wrapf(errToken, "reading federated token: %s", err)
+		}
+		return config.clientCredentials(ctx, tenantID, url.Values{
+			"client_id":             {clientID},
+			"client_assertion_type": {jwtBearerAssertion},
+			"client_assertion":      {strings.TrimSpace(string(assertion))},
+		})
+	})
+}
+
+// NewManagedIdentityCredential returns a provider of tokens of the managed identity of the host, clientID
+// selects a user assigned identity and is empty for the system assigned one
+//
+//	@param clientID
+//	@param options
+//	@return TokenProvider
+func NewManagedIdentityCredential(clientID string, options ...TokenOption) TokenProvider {
+	config := newTokenConfig(options)
+	return newCachedToken(func(ctx context.Context) (AccessToken, error) {
+		return config.managedIdentity(ctx, clientID)
+	})
+}
+
+// Token returns the cached token, requesting a new one when there is none or it is about to expire
+//
+//	@receiver c
+//	@param ctx
+//	@return string
+//	@return error
+func (c *cachedToken) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token.Token != "" && c.now().Before(c.token.ExpiresOn.Add(-tokenRefreshMargin)) {
+		return c.token.Token, nil
+	}
+	token, err := c.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.token = token
+	return token.Token, nil
+}
+
+// newCachedToken caches the tokens of fetch
+//
+//	@param fetch
+//	@return *cachedToken
+func newCachedToken(fetch tokenFunc) *cachedToken {
+	return &cachedToken{fetch: fetch, now: time.Now}
+}
+
+// newTokenConfig applies the options over the defaults, which come from the standard Azure environment variables
+//
+//	@param options
+//	@return *tokenConfig
+func newTokenConfig(options []TokenOption) *tokenConfig {
+	c := &tokenConfig{
+		authorityHost:    os.Getenv("AZURE_AUTHORITY_HOST"),
+		identityEndpoint: os.Getenv("IDENTITY_ENDPOINT"),
+		identityHeader:   os.Getenv("IDENTITY_HEADER"),
+		scope:            cognitiveServicesScope,
+		httpClient:       &http.Client{Timeout: defaultTimeoutSeconds * time.Second},
+	}
+	if c.authorityHost == "" {
+		c.authorityHost = defaultAuthorityHost
+	}
+	for _, o := range options {
+		o(c)
+	}
+	return c
+}
+
+// clientCredentials requests a token from the tenant with the client credentials grant
+//
+//	@receiver c
+//	@param ctx
+//	@param tenantID
+//	@param form the client id and its secret or assertion
+//	@return AccessToken
+//	@return error
+func (c *tokenConfig) clientCredentials(ctx context.Context, tenantID string, form url.Values) (AccessToken, error) {
+	form.Set("grant_type", "client_credentials")
+	form.Set("scope", c.scope)
+	tokenURL := fmt.Sprintf("%s/%s/oauth2/v2.0/token", strings.TrimSuffix(c.authorityHost, "/"), url.PathEscape(tenantID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return AccessToken{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return c.requestToken(req)
+}
+
+// managedIdentity requests a token from the managed identity endpoint
+//
+//	@receiver c
+//	@param ctx
+//	@param clientID
+//	@return AccessToken
+//	@return error
+func (c *tokenConfig) managedIdentity(ctx context.Context, clientID string) (AccessToken, error) {
+	endpoint, apiVersion := c.identityEndpoint, "2019-08-01"
+	if endpoint == "" {
+		endpoint, apiVersion = imdsEndpoint, "2018-02-01"
+	}
+	query := url.Values{
+		"api-version": {apiVersion},
+		// managed identity endpoints take a resource, not a scope
+		"resource": {strings.TrimSuffix(c.scope, "/.default")},
+	}
+	if clientID != "" {
+		query.Set("client_id", clientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return AccessToken{}, err
+	}
+	req.Header.Set("Metadata", "true")
+	if c.identityHeader != "" {
+		req.Header.Set("X-IDENTITY-HEADER", c.identityHeader)
+	}
+	return c.requestToken(req)
+}
+
+// tokenResponse is the token response of Entra ID and of the managed identity endpoints, which
+// encode the expiry as numbers or strings
+type tokenResponse struct {
+	AccessToken      string          `json:"access_token"`
+	ExpiresIn        json.RawMessage `json:"expires_in"`
+	ExpiresOn        json.RawMessage `json:"expires_on"`
+	Error            string          `json:"error"`
+	ErrorDescription string          `json:"error_description"`
+}
+
+// requestToken sends the token request and decodes the token and its expiry
+//
+//	@receiver c
+//	@param req
+//	@return AccessToken
+//	@return error
+func (c *tokenConfig) requestToken(req *http.Request) (AccessToken, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return AccessToken{}, errors.Wrapf(errToken, "%s", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return AccessToken{}, errors.Wrapf(errToken, "reading response: %s", err)
+	}
+	var tr tokenResponse
+	if err = json.Unmarshal(data, &tr); err != nil {
+		return AccessToken{}, errors.Wrapf(errToken, "status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if resp.StatusCode != http.StatusOK || tr.AccessToken == "" {
+		return AccessToken{}, errors.Wrapf(errToken, "status %d: %s %s", resp.StatusCode, tr.Error, tr.ErrorDescription)
+	}
+	token := AccessToken{Token: tr.AccessToken}
+	if on, ok := jsonInt(tr.ExpiresOn); ok {
+		token.ExpiresOn = time.Unix(on, 0)
+	} else if in, ok := jsonInt(tr.ExpiresIn); ok {
+		token.ExpiresOn = time.Now().Add(time.Duration(in) * time.Second)
+	} else {
+		return AccessToken{}, errors.Wrap(errToken, "response has no expiry")
+	}
+	return token, nil
+}
+
+// jsonInt decodes an integer encoded as a JSON number or string
+//
+//	@param raw
+//	@return int64
+//	@return bool
+func jsonInt(raw json.RawMessage) (int64, bool) {
+	s := strings.Trim(string(raw), `"`)
+	if s == "" {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	return n, err == nil
+}
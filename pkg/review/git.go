@@ -0,0 +1,114 @@
+package review
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"pradytpk/go-terraform-ai/pkg/utils"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var errGit = errors.New("git error")
+
+// FileDiff is the diff of a single changed .tf file
+type FileDiff struct {
+	// File is relative to the reviewed directory
+	File string
+	Diff string
+}
+
+// Changes are the .tf changes of a directory against a base revision
+type Changes struct {
+	// Prefix is the path of the reviewed directory inside the repository, e.g. "infra/"
+	Prefix string
+	Files  []FileDiff
+}
+
+// Diff returns the changes of the .tf files in dir, including uncommitted and untracked files,
+// since the merge base of base and HEAD
+//
+//	@param ctx
+//	@param dir
+//	@param base
+//	@return Changes
+//	@return error
+func Diff(ctx context.Context, dir string, base string) (Changes, error) {
+	var changes Changes
+	prefix, err := git(ctx, dir, "rev-parse", "--show-prefix")
+	if err != nil {
+		return changes, err
+	}
+	changes.Prefix = strings.TrimSpace(prefix)
+
+	rev := base
+	if mergeBase, err := git(ctx, dir, "merge-base", base, "HEAD"); err == nil {
+		rev = strings.TrimSpace(mergeBase)
+	}
+	out, err := git(ctx, dir, "diff", "--relative", "--no-color", rev, "--", "*.tf")
+	if err != nil {
+		return changes, err
+	}
+	changes.Files = splitDiff(out)
+
+	untracked, err := git(ctx, dir, "ls-files", "--others", "--exclude-standard", "--", "*.tf")
+	if err != nil {
+		return changes, err
+	}
+	for _, file := range strings.Fields(untracked) {
+		content, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			return changes, fmt.Errorf("error reading %s:%w", file, err)
+		}
+		changes.Files = append(changes.Files, FileDiff{File: file, Diff: utils.Diff("/dev/null", "b/"+file, "", string(content))})
+	}
+	sort.SliceStable(changes.Files, func(i, j int) bool {
+		return changes.Files[i].File < changes.Files[j].File
+	})
+	return changes, nil
+}
+
+// splitDiff splits the output of git diff into the diffs of each file
+//
+//	@param out
+//	@return []FileDiff
+func splitDiff(out string) []FileDiff {
+	var files []FileDiff
+	for _, part := range strings.Split(out, "diff --git ") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		header, _, _ := strings.Cut(part, "\n")
+		// the header is "a/<file> b/<file>", keep the new name
+		_, file, ok := strings.Cut(header, " b/")
+		if !ok {
+			continue
+		}
+		files = append(files, FileDiff{File: file, Diff: "diff --git " + part})
+	}
+	return files
+}
+
+// git runs a git command in dir and returns its output
+//
+//	@param ctx
+//	@param dir
+//	@param args
+//	@return string
+//	@return error
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", errors.Wrapf(errGit, "git %s: %s %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,160 @@
+package review
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"pradytpk/go-terraform-ai/pkg/terraform"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Sources of an issue
+const (
+	SourceLint = "lint"
+	SourceAI   = "ai"
+)
+
+// aiRuleID is the rule id of the issues raised by the model
+const aiRuleID = "AI-REVIEW"
+
+var errResponse = errors.New("invalid review response")
+
+// Issue is a single review comment on a changed file
+type Issue struct {
+	File       string             `json:"file"`
+	Line       int                `json:"line"`
+	Severity   terraform.Severity `json:"severity"`
+	RuleID     string             `json:"rule_id"`
+	Message    string             `json:"message"`
+	Suggestion string             `json:"suggestion,omitempty"`
+	Source     string             `json:"source"`
+}
+
+// String formats the issue for the terminal
+//
+//	@receiver i
+//	@return string
+func (i Issue) String() string {
+	text := fmt.Sprintf("[%s] %s:%d %s: %s", i.Severity, i.File, i.Line, i.RuleID, i.Message)
+	if i.Suggestion != "" {
+		text += "\n    suggestion: " + i.Suggestion
+	}
+	return text
+}
+
+// FromFindings converts the unsuppressed lint findings of a file into issues
+//
+//	@param file
+//	@param findings
+//	@return []Issue
+func FromFindings(file string, findings []terraform.Finding) []Issue {
+	var issues []Issue
+	for _, f := range findings {
+		if f.Suppressed {
+			continue
+		}
+		issues = append(issues, Issue{
+			File:     file,
+			Line:     f.Range.Start.Line,
+			Severity: f.Severity,
+			RuleID:   f.RuleID,
+			Message:  fmt.Sprintf("%s: %s", f.Resource, f.Message),
+			Source:   SourceLint,
+		})
+	}
+	return issues
+}
+
+// ParseIssues decodes the JSON array of issues in a model response, unknown severities become medium
+//
+//	@param res
+//	@return []Issue
+//	@return error
+func ParseIssues(res string) ([]Issue, error) {
+	start, end := strings.Index(res, "["), strings.LastIndex(res, "]")
+	if start < 0 || end < start {
+		return nil, errors.Wrap(errResponse, "no JSON array found")
+	}
+	var raw []struct {
+		File       string `json:"file"`
+		Line       int    `json:"line"`
+		Severity   string `json:"severity"`
+		Message    string `json:"message"`
+		Suggestion string `json:"suggestion"`
+	}
+	if err := json.Unmarshal([]byte(res[start:end+1]), &raw); err != nil {
+		return nil, errors.Wrapf(errResponse, "error decoding issues: %s", err)
+	}
+	issues := make([]Issue, 0, len(raw))
+	for _, r := range raw {
+		severity, err := terraform.ParseSeverity(r.Severity)
+		if err != nil || severity == 0 {
+			severity = terraform.SeverityMedium
+		}
+		issues = append(issues, Issue{
+			File:       r.File,
+			Line:       max(r.Line, 1),
+			Severity:   severity,
+			RuleID:     aiRuleID,
+			Message:    r.Message,
+			Suggestion: r.Suggestion,
+			Source:     SourceAI,
+		})
+	}
+	return issues, nil
+}
+
+// Sort orders the issues by file and line, the most severe first on the same line
+//
+//	@param issues
+func Sort(issues []Issue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Severity > b.Severity
+	})
+}
+
+// WriteText writes the issues one per line followed by the totals
+//
+//	@param w
+//	@param issues
+//	@return error
+func WriteText(w io.Writer, issues []Issue) error {
+	if len(issues) == 0 {
+		_, err := fmt.Fprintln(w, "No issues found.")
+		return err
+	}
+	counts := map[terraform.Severity]int{}
+	for _, issue := range issues {
+		counts[issue.Severity]++
+		if _, err := fmt.Fprintln(w, issue); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "Total: %d issues (%d critical, %d high, %d medium, %d low).\n", len(issues),
+		counts[terraform.SeverityCritical], counts[terraform.SeverityHigh], counts[terraform.SeverityMedium], counts[terraform.SeverityLow])
+	return err
+}
+
+// WriteJSON writes the issues as a JSON array
+//
+//	@param w
+//	@param issues
+//	@return error
+func WriteJSON(w io.Writer, issues []Issue) error {
+	if issues == nil {
+		issues = []Issue{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(issues)
+}
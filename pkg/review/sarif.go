@@ -0,0 +1,122 @@
+package review
+
+import (
+	"encoding/json"
+	"io"
+	"path"
+	"pradytpk/go-terraform-ai/pkg/terraform"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolName     = "terraform-assistant"
+	toolURI      = "https://github.com/pradytpk/go-terraform-ai"
+)
+
+// sarifLog is the subset of the SARIF 2.1.0 format used by code scanning tools
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+		Region struct {
+			StartLine int `json:"startLine"`
+		} `json:"region"`
+	} `json:"physicalLocation"`
+}
+
+// WriteSARIF writes the issues as a SARIF log, prefix is prepended to the file names so they
+// are relative to the repository root
+//
+//	@param w
+//	@param issues
+//	@param prefix
+//	@return error
+func WriteSARIF(w io.Writer, issues []Issue, prefix string) error {
+	descriptions := map[string]string{aiRuleID: "Issue raised by the AI review"}
+	for _, rule := range terraform.Rules() {
+		descriptions[rule.ID] = rule.Description
+	}
+
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{Name: toolName, InformationURI: toolURI, Rules: []sarifRule{}}},
+		// code scanning expects an empty array rather than null when there are no results
+		Results: []sarifResult{},
+	}
+	seen := map[string]bool{}
+	for _, issue := range issues {
+		if !seen[issue.RuleID] {
+			seen[issue.RuleID] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: issue.RuleID, ShortDescription: sarifMessage{Text: descriptions[issue.RuleID]}})
+		}
+		text := issue.Message
+		if issue.Suggestion != "" {
+			text += "\nSuggestion: " + issue.Suggestion
+		}
+		var loc sarifLocation
+		loc.PhysicalLocation.ArtifactLocation.URI = path.Join(prefix, issue.File)
+		loc.PhysicalLocation.Region.StartLine = max(issue.Line, 1)
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    issue.RuleID,
+			Level:     sarifLevel(issue.Severity),
+			Message:   sarifMessage{Text: text},
+			Locations: []sarifLocation{loc},
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}})
+}
+
+// sarifLevel maps a severity to a SARIF level
+//
+//	@param severity
+//	@return string
+func sarifLevel(severity terraform.Severity) string {
+	switch {
+	case severity >= terraform.SeverityHigh:
+		return "error"
+	case severity == terraform.SeverityMedium:
+		return "warning"
+	}
+	return "note"
+}
@@ -0,0 +1,293 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"pradytpk/go-terraform-ai/pkg/utils"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultDir is the directory, relative to the working dir, holding the sessions
+const DefaultDir = ".terraform-assistant/sessions"
+
+// Roles of the messages
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Kinds of the recorded events
+const (
+	EventValidation = "validation"
+	EventFile       = "file"
+	EventPlan       = "plan"
+	EventApply      = "apply"
+	EventTool       = "tool"
+)
+
+// Last resumes the most recently updated session
+const Last = "last"
+
+var errSession = errors.New("session not found")
+
+// Message is a turn of the conversation
+type Message struct {
+	Role    string    `json:"role"`
+	Content string    `json:"content"`
+	Time    time.Time `json:"time"`
+}
+
+// Event is a step of the session outside of the conversation, e.g. a validation, a written file or an apply
+type Event struct {
+	Kind   string    `json:"kind"`
+	Detail string    `json:"detail"`
+	Error  string    `json:"error,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// Session is a conversation with the model, the versions of the configuration it produced and
+// what was done with them
+type Session struct {
+	ID      string    `json:"id"`
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+	// Command is the command that started the session, run or chat
+	Command string `json:"command"`
+	// Deployment is the OpenAI or Azure deployment and Model the model behind it, sessions written
+	// before the model was separate only hold the deployment in Model
+	Deployment string `json:"deployment,omitempty"`
+	Model      string `json:"model"`
+	// File is the configuration file the session writes
+	File     string    `json:"file"`
+	Messages []Message `json:"messages"`
+	// Versions are the generated configurations, the last one is the current
+	Versions []string `json:"versions"`
+	Events   []Event  `json:"events,omitempty"`
+}
+
+// New creates a session with a unique id made of the creation time and a random suffix
+//
+//	@param command
+//	@param deployment
+//	@param model
+//	@param file
+//	@return *Session
+func New(command string, deployment string, model string, file string) *Session {
+	now := time.Now()
+	suffix := make([]byte, 3)
+	_, _ = rand.Read(suffix)
+	return &Session{
+		ID:         fmt.Sprintf("%s-%s", now.Format("20060102-150405"), hex.EncodeToString(suffix)),
+		Created:    now,
+		Updated:    now,
+		Command:    command,
+		Deployment: deployment,
+		Model:      model,
+		File:       file,
+	}
+}
+
+// Add appends a message to the conversation
+//
+//	@receiver s
+//	@param role
+//	@param content
+func (s *Session) Add(role string, content string) {
+	s.Messages = append(s.Messages, Message{Role: role, Content: content, Time: time.Now()})
+	s.Updated = time.Now()
+}
+
+// Record appends an event, err is the failure of the step if any
+//
+//	@receiver s
+//	@param kind
+//	@param detail
+//	@param err
+func (s *Session) Record(kind string, detail string, err error) {
+	e := Event{Kind: kind, Detail: detail, Time: time.Now()}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	s.Events = append(s.Events, e)
+	s.Updated = e.Time
+}
+
+// Current returns the current configuration, empty when none was generated yet
+//
+//	@receiver s
+//	@return string
+func (s *Session) Current() string {
+	if len(s.Versions) == 0 {
+		return ""
+	}
+	return s.Versions[len(s.Versions)-1]
+}
+
+// Push makes the configuration the current version
+//
+//	@receiver s
+//	@param config
+func (s *Session) Push(config string) {
+	if config == s.Current() {
+		return
+	}
+	s.Versions = append(s.Versions, config)
+	s.Updated = time.Now()
+}
+
+// Undo drops the current version, it reports false when there is nothing to undo
+//
+//	@receiver s
+//	@return bool
+func (s *Session) Undo() bool {
+	if len(s.Versions) == 0 {
+		return false
+	}
+	s.Versions = s.Versions[:len(s.Versions)-1]
+	s.Updated = time.Now()
+	return true
+}
+
+// Reset clears the conversation and the versions
+//
+//	@receiver s
+func (s *Session) Reset() {
+	s.Messages = nil
+	s.Versions = nil
+	s.Updated = time.Now()
+}
+
+// Title returns the first user message shortened to a line, to recognize the session
+//
+//	@receiver s
+//	@return string
+func (s *Session) Title() string {
+	for _, m := range s.Messages {
+		if m.Role != RoleUser {
+			continue
+		}
+		title := strings.Join(strings.Fields(m.Content), " ")
+		if len(title) > 60 {
+			title = title[:57] + "..."
+		}
+		return title
+	}
+	return "(empty)"
+}
+
+// Save writes the session into dir as <id>.json. Credentials of the messages and the versions are
+// redacted in the file only, the session keeps the real values to write the configuration.
+//
+//	@param dir
+//	@param s
+//	@return error
+func Save(dir string, s *Session) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("error creating session dir:%w", err)
+	}
+	data, err := json.MarshalIndent(s.redacted(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding session:%w", err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, s.ID+".json"), data, 0o600); err != nil {
+		return fmt.Errorf("error writing session:%w", err)
+	}
+	return nil
+}
+
+// redacted returns a copy of the session with the credentials of the messages and versions redacted
+//
+//	@receiver s
+//	@return *Session
+func (s *Session) redacted() *Session {
+	c := *s
+	c.Messages = make([]Message, len(s.Messages))
+	for i, m := range s.Messages {
+		m.Content, _ = utils.RedactSecrets(m.Content)
+		c.Messages[i] = m
+	}
+	c.Versions = make([]string, len(s.Versions))
+	for i, v := range s.Versions {
+		c.Versions[i], _ = utils.RedactSecrets(v)
+	}
+	return &c
+}
+
+// Load reads the session with the id from dir, Last loads the most recently updated one
+//
+//	@param dir
+//	@param id
+//	@return *Session
+//	@return error
+func Load(dir string, id string) (*Session, error) {
+	if id == Last {
+		sessions, err := List(dir)
+		if err != nil {
+			return nil, err
+		}
+		if len(sessions) == 0 {
+			return nil, errors.Wrapf(errSession, "no sessions in %s", dir)
+		}
+		return sessions[0], nil
+	}
+	data, err := os.ReadFile(filepath.Join(dir, filepath.Base(id)+".json"))
+	if os.IsNotExist(err) {
+		return nil, errors.Wrapf(errSession, "no session %q", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading session:%w", err)
+	}
+	var s Session
+	if err = json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("error decoding session %s:%w", id, err)
+	}
+	return &s, nil
+}
+
+// Delete removes the session with the id from dir
+//
+//	@param dir
+//	@param id
+//	@return error
+func Delete(dir string, id string) error {
+	err := os.Remove(filepath.Join(dir, filepath.Base(id)+".json"))
+	if os.IsNotExist(err) {
+		return errors.Wrapf(errSession, "no session %q", id)
+	}
+	if err != nil {
+		return fmt.Errorf("error deleting session:%w", err)
+	}
+	return nil
+}
+
+// List returns the sessions in dir, the most recently updated first
+//
+//	@param dir
+//	@return []*Session
+//	@return error
+func List(dir string) ([]*Session, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing sessions:%w", err)
+	}
+	sessions := make([]*Session, 0, len(files))
+	for _, file := range files {
+		s, err := Load(dir, strings.TrimSuffix(filepath.Base(file), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].Updated.After(sessions[j].Updated)
+	})
+	return sessions, nil
+}
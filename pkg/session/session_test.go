@@ -0,0 +1,66 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveRedacts(t *testing.T) {
+	const config = "resource \"azurerm_mssql_server\" \"db\" {\n  administrator_login_password = \"Sup3rS3cret!\"\n}\n"
+	tests := []struct {
+		name string
+		// fill adds the credential to the session
+		fill func(s *Session)
+	}{
+		{name: "version", fill: func(s *Session) { s.Push(config) }},
+		{name: "message", fill: func(s *Session) { s.Add(RoleUser, "use "+config) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			s := New("chat", "prod-chat", "gpt-4o", "main.tf")
+			tt.fill(s)
+			if err := Save(dir, s); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+			if s.Current() != "" && s.Current() != config {
+				t.Errorf("Current() = %q after Save, want the real configuration", s.Current())
+			}
+			if len(s.Messages) > 0 && !strings.Contains(s.Messages[0].Content, "Sup3rS3cret!") {
+				t.Errorf("message = %q after Save, want the real content", s.Messages[0].Content)
+			}
+			data, err := os.ReadFile(filepath.Join(dir, s.ID+".json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(data), "Sup3rS3cret!") || !strings.Contains(string(data), "[REDACTED]") {
+				t.Errorf("saved session = %s, want the credential redacted", data)
+			}
+			loaded, err := Load(dir, s.ID)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if loaded.Deployment != "prod-chat" || loaded.Model != "gpt-4o" {
+				t.Errorf("Load() deployment and model = %q, %q, want prod-chat, gpt-4o", loaded.Deployment, loaded.Model)
+			}
+		})
+	}
+}
+
+func TestPushUndo(t *testing.T) {
+	s := New("run", "gpt-4o", "gpt-4o", "main.tf")
+	s.Push("a")
+	s.Push("a")
+	s.Push("b")
+	if len(s.Versions) != 2 || s.Current() != "b" {
+		t.Fatalf("Versions = %v, want [a b]", s.Versions)
+	}
+	if !s.Undo() || s.Current() != "a" {
+		t.Errorf("Current() after Undo = %q, want a", s.Current())
+	}
+	if !s.Undo() || s.Undo() {
+		t.Errorf("Undo() of an empty session succeeded")
+	}
+}
@@ -0,0 +1,29 @@
+package terraform
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// ManagedAddresses returns the addresses of the resource and module blocks of a configuration,
+// e.g. aws_s3_bucket.logs or module.network, which are the targets able to destroy what it creates
+//
+//	@param name
+//	@param src
+//	@return []string
+//	@return error
+func ManagedAddresses(name string, src []byte) ([]string, error) {
+	file, diags := hclsyntax.ParseConfig(src, name, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("error parsing %s:%w", name, diags)
+	}
+	var addresses []string
+	for _, block := range file.Body.(*hclsyntax.Body).Blocks {
+		if (block.Type == "resource" && len(block.Labels) == 2) || (block.Type == "module" && len(block.Labels) == 1) {
+			addresses = append(addresses, blockAddress(block))
+		}
+	}
+	return addresses, nil
+}
@@ -0,0 +1,174 @@
+package terraform
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var errBackend = errors.New("invalid backend configuration")
+
+// InitOptions are the options of terraform init
+type InitOptions struct {
+	// BackendConfigs are -backend-config values, either a file path or a key=value pair
+	BackendConfigs []string
+	Reconfigure    bool
+	// MigrateState copies the existing state to the new backend, overwriting any state already in it.
+	// Terraform cannot ask for the copy when run by the assistant, so the caller confirms it.
+	MigrateState bool
+	Upgrade      bool
+}
+
+// backendRequired lists the settings each known backend needs, either in the
+// backend block or through -backend-config
+var backendRequired = map[string][]string{
+	"s3":         {"bucket", "key", "region"},
+	"azurerm":    {"storage_account_name", "container_name", "key"},
+	"gcs":        {"bucket"},
+	"local":      nil,
+	"remote":     {"organization"},
+	"http":       {"address"},
+	"consul":     {"path"},
+	"pg":         {"conn_str"},
+	"kubernetes": {"secret_suffix"},
+	"cos":        {"bucket"},
+	"oss":        {"bucket"},
+}
+
+// backendEnv lists environment variables that can provide a backend setting
+var backendEnv = map[string][]string{
+	"region":               {"AWS_REGION", "AWS_DEFAULT_REGION"},
+	"storage_account_name": {"ARM_STORAGE_ACCOUNT_NAME"},
+	"conn_str":             {"PG_CONN_STR"},
+	"address":              {"TF_HTTP_ADDRESS"},
+}
+
+// ValidateBackend checks the backend block of the configuration in dir together with the
+// -backend-config values before init runs
+//
+//	@param dir
+//	@param opts
+//	@return error
+func ValidateBackend(dir string, opts InitOptions) error {
+	if opts.Reconfigure && opts.MigrateState {
+		return errors.Wrap(errBackend, "reconfigure and migrate-state are mutually exclusive")
+	}
+	backendType, settings, err := findBackend(dir)
+	if err != nil {
+		return err
+	}
+	if backendType == "" {
+		if len(opts.BackendConfigs) > 0 {
+			return errors.Wrap(errBackend, "backend-config is set but the configuration has no backend block")
+		}
+		return nil
+	}
+	required, ok := backendRequired[backendType]
+	if !ok {
+		return errors.Wrapf(errBackend, "unknown backend type %q", backendType)
+	}
+	for _, config := range opts.BackendConfigs {
+		keys, err := backendConfigKeys(dir, config)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			settings[key] = true
+		}
+	}
+	var missing []string
+	for _, key := range required {
+		if !settings[key] && !envSet(backendEnv[key]) {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return errors.Wrapf(errBackend, "backend %q is missing %s, set it in the backend block or with --backend-config", backendType, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// findBackend returns the type and settings of the single backend block of the configuration
+//
+//	@param dir
+//	@return string
+//	@return map[string]bool
+//	@return error
+func findBackend(dir string) (string, map[string]bool, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return "", nil, fmt.Errorf("error listing tf files:%w", err)
+	}
+	sort.Strings(files)
+	var (
+		backendType string
+		settings    = map[string]bool{}
+		found       []string
+	)
+	for _, file := range files {
+		body, _, err := parseFile(file)
+		if err != nil {
+			return "", nil, err
+		}
+		for _, tf := range childBlocks(body, "terraform") {
+			for _, backend := range childBlocks(tf.Body, "backend") {
+				if len(backend.Labels) != 1 {
+					return "", nil, errors.Wrapf(errBackend, "backend block in %s must have a single type label", file)
+				}
+				backendType = backend.Labels[0]
+				found = append(found, fmt.Sprintf("%s (%s)", backendType, filepath.Base(file)))
+				for name := range backend.Body.Attributes {
+					settings[name] = true
+				}
+			}
+		}
+	}
+	if len(found) > 1 {
+		return "", nil, errors.Wrapf(errBackend, "only one backend block is allowed but found %s", strings.Join(found, ", "))
+	}
+	return backendType, settings, nil
+}
+
+// backendConfigKeys returns the keys set by a -backend-config value, a key=value pair or a file
+//
+//	@param dir
+//	@param config
+//	@return []string
+//	@return error
+func backendConfigKeys(dir string, config string) ([]string, error) {
+	if key, _, found := strings.Cut(config, "="); found {
+		if strings.TrimSpace(key) == "" {
+			return nil, errors.Wrapf(errBackend, "backend-config %q has an empty key", config)
+		}
+		return []string{strings.TrimSpace(key)}, nil
+	}
+	path := config
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, errors.Wrapf(errBackend, "backend-config %q is neither key=value nor a readable file", config)
+	}
+	keys, err := VarFileNames(path)
+	if err != nil {
+		return nil, errors.Wrapf(errBackend, "error reading backend-config file: %s", err)
+	}
+	return keys, nil
+}
+
+// envSet reports whether any of the environment variables is set
+//
+//	@param names
+//	@return bool
+func envSet(names []string) bool {
+	for _, name := range names {
+		if os.Getenv(name) != "" {
+			return true
+		}
+	}
+	return false
+}
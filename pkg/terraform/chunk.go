@@ -0,0 +1,112 @@
+package terraform
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var errNoConfig = errors.New("no terraform configuration found")
+
+// Block is a top level block of a configuration file with its source
+type Block struct {
+	File    string
+	Type    string
+	Address string
+	Source  string
+}
+
+// ReadBlocks parses a .tf file, or all the .tf files of a module directory, into their top level blocks
+//
+//	@param path
+//	@return []Block
+//	@return error
+func ReadBlocks(path string) ([]Block, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading path:%w", err)
+	}
+	files := []string{path}
+	if info.IsDir() {
+		files, err = filepath.Glob(filepath.Join(path, "*.tf"))
+		if err != nil {
+			return nil, fmt.Errorf("error listing tf files:%w", err)
+		}
+		sort.Strings(files)
+	}
+	var blocks []Block
+	for _, file := range files {
+		body, src, err := parseFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, block := range body.Blocks {
+			blocks = append(blocks, Block{
+				File:    filepath.Base(file),
+				Type:    block.Type,
+				Address: blockAddress(block),
+				Source:  string(block.Range().SliceBytes(src)),
+			})
+		}
+	}
+	if len(blocks) == 0 {
+		return nil, errors.Wrapf(errNoConfig, "in %s", path)
+	}
+	return blocks, nil
+}
+
+// Chunk groups consecutive blocks into chunks of at most maxChars characters, a block larger
+// than maxChars gets a chunk of its own. Every chunk starts each file with a `# file:` comment.
+//
+//	@param blocks
+//	@param maxChars
+//	@return []string
+func Chunk(blocks []Block, maxChars int) []string {
+	var (
+		chunks  []string
+		current strings.Builder
+		file    string
+	)
+	for _, block := range blocks {
+		header := ""
+		if block.File != file || current.Len() == 0 {
+			header = fmt.Sprintf("# file: %s\n", block.File)
+		}
+		text := header + block.Source + "\n\n"
+		if current.Len() > 0 && current.Len()+len(text) > maxChars {
+			chunks = append(chunks, current.String())
+			current.Reset()
+			text = fmt.Sprintf("# file: %s\n", block.File) + block.Source + "\n\n"
+		}
+		current.WriteString(text)
+		file = block.File
+	}
+	if current.Len() > 0 {
+		chunks = append(chunks, current.String())
+	}
+	return chunks
+}
+
+// Inventory lists the addresses of the blocks grouped by block type, e.g. "resource: aws_instance.web"
+//
+//	@param blocks
+//	@return string
+func Inventory(blocks []Block) string {
+	byType := map[string][]string{}
+	var types []string
+	for _, block := range blocks {
+		if _, ok := byType[block.Type]; !ok {
+			types = append(types, block.Type)
+		}
+		byType[block.Type] = append(byType[block.Type], block.Address)
+	}
+	lines := make([]string, 0, len(types))
+	for _, typ := range types {
+		lines = append(lines, fmt.Sprintf("%s: %s", typ, strings.Join(byType[typ], ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
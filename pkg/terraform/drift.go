@@ -0,0 +1,200 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// maxDriftValue is the length from which drifted values are shortened in the report
+const maxDriftValue = 60
+
+// DriftedResource is a resource changed outside of terraform
+type DriftedResource struct {
+	Address string
+	Type    string
+	// Action is update when the resource was changed and delete when it no longer exists
+	Action string
+	// Attributes are the changed attributes, e.g. tags.Owner: "alice" -> "bob"
+	Attributes []AttributeDrift
+}
+
+// AttributeDrift is the change of a single attribute, sensitive values are hidden
+type AttributeDrift struct {
+	Path   string
+	Before string
+	After  string
+}
+
+// String formats the attribute change
+//
+//	@receiver a
+//	@return string
+func (a AttributeDrift) String() string {
+	return fmt.Sprintf("%s: %s -> %s", a.Path, a.Before, a.After)
+}
+
+// Drift returns the resources of a refresh-only plan that changed outside of terraform
+//
+//	@param plan
+//	@return []DriftedResource
+func Drift(plan *tfjson.Plan) []DriftedResource {
+	var drifts []DriftedResource
+	for _, rc := range plan.ResourceDrift {
+		if rc.Change == nil || rc.Change.Actions.NoOp() {
+			continue
+		}
+		d := DriftedResource{Address: rc.Address, Type: rc.Type, Action: planAction(rc.Change.Actions)}
+		if d.Action == "update" {
+			d.Attributes = diffValues("", rc.Change.Before, rc.Change.After, rc.Change.BeforeSensitive, rc.Change.AfterSensitive)
+		}
+		drifts = append(drifts, d)
+	}
+	sort.SliceStable(drifts, func(i, j int) bool {
+		if drifts[i].Type != drifts[j].Type {
+			return drifts[i].Type < drifts[j].Type
+		}
+		return drifts[i].Address < drifts[j].Address
+	})
+	return drifts
+}
+
+// FormatDrift renders the drifted resources grouped by resource type
+//
+//	@param drifts
+//	@return string
+func FormatDrift(drifts []DriftedResource) string {
+	if len(drifts) == 0 {
+		return "No drift detected."
+	}
+	var b strings.Builder
+	deleted := 0
+	for i, d := range drifts {
+		if i == 0 || drifts[i-1].Type != d.Type {
+			fmt.Fprintf(&b, "%s:\n", d.Type)
+		}
+		if d.Action == "delete" {
+			deleted++
+			fmt.Fprintf(&b, "  %-3s %s (deleted outside of terraform)\n", actionSymbols["delete"], d.Address)
+			continue
+		}
+		fmt.Fprintf(&b, "  %-3s %s (changed outside of terraform)\n", actionSymbols[d.Action], d.Address)
+		for _, a := range d.Attributes {
+			fmt.Fprintf(&b, "        %s\n", a)
+		}
+	}
+	fmt.Fprintf(&b, "Total: %d drifted resources, %d changed, %d deleted.", len(drifts), len(drifts)-deleted, deleted)
+	return b.String()
+}
+
+// diffValues walks the objects of before and after and returns the changed leaf values
+//
+//	@param path
+//	@param before
+//	@param after
+//	@param beforeSensitive
+//	@param afterSensitive
+//	@return []AttributeDrift
+func diffValues(path string, before, after, beforeSensitive, afterSensitive interface{}) []AttributeDrift {
+	if reflect.DeepEqual(before, after) {
+		return nil
+	}
+	beforeMap, okBefore := before.(map[string]interface{})
+	afterMap, okAfter := after.(map[string]interface{})
+	if !okBefore || !okAfter {
+		return []AttributeDrift{{
+			Path:   path,
+			Before: driftValue(before, beforeSensitive),
+			After:  driftValue(after, afterSensitive),
+		}}
+	}
+	keys := map[string]bool{}
+	for k := range beforeMap {
+		keys[k] = true
+	}
+	for k := range afterMap {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var drifts []AttributeDrift
+	for _, k := range sorted {
+		child := k
+		if path != "" {
+			child = path + "." + k
+		}
+		drifts = append(drifts, diffValues(child, beforeMap[k], afterMap[k], sensitiveChild(beforeSensitive, k), sensitiveChild(afterSensitive, k))...)
+	}
+	return drifts
+}
+
+// sensitiveChild returns the sensitivity of an object attribute, true marks the whole value
+//
+//	@param sensitive
+//	@param key
+//	@return interface{}
+func sensitiveChild(sensitive interface{}, key string) interface{} {
+	switch s := sensitive.(type) {
+	case bool:
+		return s
+	case map[string]interface{}:
+		return s[key]
+	}
+	return nil
+}
+
+// anySensitive reports whether the sensitivity marks the value or any of its elements or attributes,
+// lists are marked per element, e.g. [{"value": true}]
+//
+//	@param sensitive
+//	@return bool
+func anySensitive(sensitive interface{}) bool {
+	switch s := sensitive.(type) {
+	case bool:
+		return s
+	case []interface{}:
+		for _, e := range s {
+			if anySensitive(e) {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		for _, v := range s {
+			if anySensitive(v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// driftValue formats a value for the report, values with any sensitive part are hidden as a whole
+//
+//	@param value
+//	@param sensitive
+//	@return string
+func driftValue(value interface{}, sensitive interface{}) string {
+	if anySensitive(sensitive) {
+		return "(sensitive)"
+	}
+	if value == nil {
+		return "null"
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	text := string(encoded)
+	if len(text) > maxDriftValue {
+		text = text[:maxDriftValue-3] + "..."
+	}
+	return text
+}
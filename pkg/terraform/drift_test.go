@@ -0,0 +1,134 @@
+package terraform
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func TestDiffValues(t *testing.T) {
+	tests := []struct {
+		name            string
+		before, after   interface{}
+		beforeSensitive interface{}
+		afterSensitive  interface{}
+		want            []AttributeDrift
+	}{
+		{
+			name:   "equal",
+			before: map[string]interface{}{"name": "web"},
+			after:  map[string]interface{}{"name": "web"},
+		},
+		{
+			name:   "nested attributes in key order",
+			before: map[string]interface{}{"tags": map[string]interface{}{"Owner": "alice", "Env": "dev"}},
+			after:  map[string]interface{}{"tags": map[string]interface{}{"Owner": "bob", "Env": "prod"}},
+			want: []AttributeDrift{
+				{Path: "tags.Env", Before: `"dev"`, After: `"prod"`},
+				{Path: "tags.Owner", Before: `"alice"`, After: `"bob"`},
+			},
+		},
+		{
+			name:   "added and removed attributes",
+			before: map[string]interface{}{"a": "x"},
+			after:  map[string]interface{}{"b": float64(1)},
+			want: []AttributeDrift{
+				{Path: "a", Before: `"x"`, After: "null"},
+				{Path: "b", Before: "null", After: "1"},
+			},
+		},
+		{
+			name:           "sensitive attribute",
+			before:         map[string]interface{}{"password": "old"},
+			after:          map[string]interface{}{"password": "new"},
+			afterSensitive: map[string]interface{}{"password": true},
+			want:           []AttributeDrift{{Path: "password", Before: `"old"`, After: "(sensitive)"}},
+		},
+		{
+			name:            "whole value sensitive",
+			before:          map[string]interface{}{"value": "old"},
+			after:           map[string]interface{}{"value": "new"},
+			beforeSensitive: true,
+			afterSensitive:  true,
+			want:            []AttributeDrift{{Path: "value", Before: "(sensitive)", After: "(sensitive)"}},
+		},
+		{
+			name:            "list with a sensitive element",
+			before:          map[string]interface{}{"secrets": []interface{}{"a", "b"}},
+			after:           map[string]interface{}{"secrets": []interface{}{"a", "c"}},
+			beforeSensitive: map[string]interface{}{"secrets": []interface{}{false, true}},
+			afterSensitive:  map[string]interface{}{"secrets": []interface{}{false, true}},
+			want:            []AttributeDrift{{Path: "secrets", Before: "(sensitive)", After: "(sensitive)"}},
+		},
+		{
+			name:            "list of blocks with a sensitive attribute",
+			before:          map[string]interface{}{"setting": []interface{}{map[string]interface{}{"name": "a", "value": "x"}}},
+			after:           map[string]interface{}{"setting": []interface{}{map[string]interface{}{"name": "a", "value": "y"}}},
+			beforeSensitive: map[string]interface{}{"setting": []interface{}{map[string]interface{}{"value": true}}},
+			afterSensitive:  map[string]interface{}{"setting": []interface{}{map[string]interface{}{"value": true}}},
+			want:            []AttributeDrift{{Path: "setting", Before: "(sensitive)", After: "(sensitive)"}},
+		},
+		{
+			name:            "list without sensitive elements",
+			before:          map[string]interface{}{"ports": []interface{}{float64(80)}},
+			after:           map[string]interface{}{"ports": []interface{}{float64(80), float64(443)}},
+			beforeSensitive: map[string]interface{}{"ports": []interface{}{false}},
+			afterSensitive:  map[string]interface{}{"ports": []interface{}{false, false}},
+			want:            []AttributeDrift{{Path: "ports", Before: "[80]", After: "[80,443]"}},
+		},
+		{
+			name:   "long value shortened",
+			before: map[string]interface{}{"policy": strings.Repeat("a", 10)},
+			after:  map[string]interface{}{"policy": strings.Repeat("b", 100)},
+			want:   []AttributeDrift{{Path: "policy", Before: `"aaaaaaaaaa"`, After: `"` + strings.Repeat("b", maxDriftValue-4) + "..."}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffValues("", tt.before, tt.after, tt.beforeSensitive, tt.afterSensitive)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffValues() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDrift(t *testing.T) {
+	plan := &tfjson.Plan{
+		ResourceDrift: []*tfjson.ResourceChange{
+			{
+				Address: "aws_s3_bucket.logs",
+				Type:    "aws_s3_bucket",
+				Change: &tfjson.Change{
+					Actions: tfjson.Actions{tfjson.ActionUpdate},
+					Before:  map[string]interface{}{"tags": map[string]interface{}{"Owner": "alice"}},
+					After:   map[string]interface{}{"tags": map[string]interface{}{"Owner": "bob"}},
+				},
+			},
+			{
+				Address: "aws_instance.web",
+				Type:    "aws_instance",
+				Change:  &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionDelete}},
+			},
+			{
+				Address: "aws_instance.db",
+				Type:    "aws_instance",
+				Change:  &tfjson.Change{Actions: tfjson.Actions{tfjson.ActionNoop}},
+			},
+		},
+	}
+	want := []DriftedResource{
+		{Address: "aws_instance.web", Type: "aws_instance", Action: "delete"},
+		{
+			Address:    "aws_s3_bucket.logs",
+			Type:       "aws_s3_bucket",
+			Action:     "update",
+			Attributes: []AttributeDrift{{Path: "tags.Owner", Before: `"alice"`, After: `"bob"`}},
+		},
+	}
+	if got := Drift(plan); !reflect.DeepEqual(got, want) {
+		t.Errorf("Drift() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,228 @@
+package terraform
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Types of the events of the terraform machine readable UI
+const (
+	EventVersion       = "version"
+	EventPlannedChange = "planned_change"
+	EventChangeSummary = "change_summary"
+	EventApplyStart    = "apply_start"
+	EventApplyProgress = "apply_progress"
+	EventApplyComplete = "apply_complete"
+	EventApplyErrored  = "apply_errored"
+	EventRefreshStart  = "refresh_start"
+	EventRefreshDone   = "refresh_complete"
+	EventResourceDrift = "resource_drift"
+	EventDiagnostic    = "diagnostic"
+	EventOutputs       = "outputs"
+	// EventText is used for lines of the stream that are not JSON
+	EventText = "text"
+)
+
+// Event is a message of the terraform machine readable UI (`-json`)
+type Event struct {
+	Level      string         `json:"@level"`
+	Message    string         `json:"@message"`
+	Module     string         `json:"@module"`
+	Timestamp  time.Time      `json:"@timestamp"`
+	Type       string         `json:"type"`
+	Hook       *EventHook     `json:"hook,omitempty"`
+	Change     *EventChange   `json:"change,omitempty"`
+	Changes    *ChangeSummary `json:"changes,omitempty"`
+	Diagnostic *Diagnostic    `json:"diagnostic,omitempty"`
+}
+
+// hasPayload reports whether the payload matching the event type is present
+//
+//	@receiver e
+//	@return bool
+func (e Event) hasPayload() bool {
+	switch e.Type {
+	case EventPlannedChange, EventResourceDrift:
+		return e.Change != nil
+	case EventApplyStart, EventApplyProgress, EventApplyComplete, EventApplyErrored, EventRefreshStart, EventRefreshDone:
+		return e.Hook != nil
+	case EventChangeSummary:
+		return e.Changes != nil
+	case EventDiagnostic:
+		return e.Diagnostic != nil
+	}
+	return true
+}
+
+// EventResource identifies the resource of an event
+type EventResource struct {
+	Addr            string `json:"addr"`
+	Module          string `json:"module"`
+	Resource        string `json:"resource"`
+	ResourceType    string `json:"resource_type"`
+	ResourceName    string `json:"resource_name"`
+	ImpliedProvider string `json:"implied_provider"`
+}
+
+// EventHook is the payload of the apply and refresh events
+type EventHook struct {
+	Resource EventResource `json:"resource"`
+	Action   string        `json:"action"`
+	IDKey    string        `json:"id_key,omitempty"`
+	IDValue  string        `json:"id_value,omitempty"`
+	Elapsed  float64       `json:"elapsed_seconds,omitempty"`
+}
+
+// EventChange is the payload of the planned_change and resource_drift events
+type EventChange struct {
+	Resource EventResource `json:"resource"`
+	Action   string        `json:"action"`
+	Reason   string        `json:"reason,omitempty"`
+}
+
+// ChangeSummary is the payload of the change_summary event
+type ChangeSummary struct {
+	Add       int    `json:"add"`
+	Change    int    `json:"change"`
+	Import    int    `json:"import"`
+	Remove    int    `json:"remove"`
+	Operation string `json:"operation"`
+}
+
+// Diagnostic is the payload of the diagnostic event
+type Diagnostic struct {
+	Severity string           `json:"severity"`
+	Summary  string           `json:"summary"`
+	Detail   string           `json:"detail"`
+	Address  string           `json:"address,omitempty"`
+	Range    *DiagnosticRange `json:"range,omitempty"`
+}
+
+// DiagnosticRange is the location in the configuration a diagnostic points to
+type DiagnosticRange struct {
+	Filename string `json:"filename"`
+	Start    struct {
+		Line   int `json:"line"`
+		Column int `json:"column"`
+	} `json:"start"`
+}
+
+// EventHandler consumes the events of a terraform command
+type EventHandler interface {
+	HandleEvent(event Event)
+}
+
+// EventHandlerFunc adapts a function to an EventHandler
+type EventHandlerFunc func(event Event)
+
+// HandleEvent calls f
+//
+//	@receiver f
+//	@param event
+func (f EventHandlerFunc) HandleEvent(event Event) {
+	f(event)
+}
+
+// ParseEvents reads a `-json` stream line by line and passes every event to the handler
+//
+//	@param r
+//	@param handler
+//	@return error
+func ParseEvents(r io.Reader, handler EventHandler) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		handler.HandleEvent(ParseEvent(scanner.Bytes()))
+	}
+	return scanner.Err()
+}
+
+// ParseEvent decodes a single line of the stream, lines that are not JSON become EventText
+//
+//	@param line
+//	@return Event
+func ParseEvent(line []byte) Event {
+	var event Event
+	if err := json.Unmarshal(line, &event); err != nil || event.Type == "" {
+		return Event{Type: EventText, Message: string(line), Timestamp: time.Now()}
+	}
+	return event
+}
+
+// eventWriter is an io.Writer that parses complete lines into events
+type eventWriter struct {
+	mu      sync.Mutex
+	handler EventHandler
+	buf     bytes.Buffer
+}
+
+// NewEventWriter returns a writer that passes every line written to it as an event to the handler,
+// Close flushes the last incomplete line
+//
+//	@param handler
+//	@return io.WriteCloser
+func NewEventWriter(handler EventHandler) io.WriteCloser {
+	return &eventWriter{handler: handler}
+}
+
+// Write buffers p and handles the complete lines
+//
+//	@receiver w
+//	@param p
+//	@return int
+//	@return error
+func (w *eventWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf.Write(p)
+	for {
+		line, err := w.buf.ReadBytes('\n')
+		if err != nil {
+			// keep the incomplete line for the next write
+			w.buf.Write(line)
+			return len(p), nil
+		}
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			w.handler.HandleEvent(ParseEvent(line))
+		}
+	}
+}
+
+// Close handles the last incomplete line
+//
+//	@receiver w
+//	@return error
+func (w *eventWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if line := bytes.TrimSpace(w.buf.Bytes()); len(line) > 0 {
+		w.handler.HandleEvent(ParseEvent(line))
+	}
+	w.buf.Reset()
+	return nil
+}
+
+// jsonHandler writes every event as a JSON line
+type jsonHandler struct {
+	enc *json.Encoder
+}
+
+// NewJSONHandler returns a handler that writes the events as JSON lines, for CI and other tools
+//
+//	@param w
+//	@return EventHandler
+func NewJSONHandler(w io.Writer) EventHandler {
+	return &jsonHandler{enc: json.NewEncoder(w)}
+}
+
+// HandleEvent encodes the event
+//
+//	@receiver h
+//	@param event
+func (h *jsonHandler) HandleEvent(event Event) {
+	_ = h.enc.Encode(event)
+}
@@ -3,41 +3,380 @@ package terraform
 import (
 	"context"
 	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
 	"time"
 
-	"github.com/briandowns/spinner"
+	"github.com/hashicorp/terraform-exec/tfexec"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/pkg/errors"
 )
 
 // Init initializes the terraform instances
 //
 //	@receiver ter
+//	@param ctx
+//	@param opts
 //	@return error
-func (ter *Terraform) Init() error {
-	spin := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
-	spin.Start()
+func (ter *Terraform) Init(ctx context.Context, opts InitOptions) error {
+	ctx, cancel := withTimeout(ctx, ter.Timeouts.Init)
+	defer cancel()
+	log, err := ter.startOp("init")
+	if err != nil {
+		return err
+	}
+	initOpts := []tfexec.InitOption{
+		tfexec.Reconfigure(opts.Reconfigure),
+		tfexec.Upgrade(opts.Upgrade),
+		// -force-copy implies -migrate-state and answers yes to the copy question, which
+		// terraform asks whenever there is state to copy and cannot ask with -input=false
+		tfexec.ForceCopy(opts.MigrateState),
+	}
+	for _, config := range opts.BackendConfigs {
+		initOpts = append(initOpts, tfexec.BackendConfig(config))
+	}
+	return log.done(ctx, "init", ter.Exec.Init(ctx, initOpts...))
+}
+
+// Plan creates a plan of the Terraform configuration and returns its JSON representation
+//
+//	@receiver ter
+//	@param ctx
+//	@return *tfjson.Plan
+//	@return error
+func (ter *Terraform) Plan(ctx context.Context) (*tfjson.Plan, error) {
+	planFile, err := ter.planToFile(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(planFile)
+	return ter.showPlan(ctx, planFile)
+}
+
+// ShowPlan reads an existing plan file created with terraform plan -out
+//
+//	@receiver ter
+//	@param ctx
+//	@param planFile
+//	@return *tfjson.Plan
+//	@return error
+func (ter *Terraform) ShowPlan(ctx context.Context, planFile string) (*tfjson.Plan, error) {
+	return ter.showPlan(ctx, planFile)
+}
+
+// Apply plans the Terraform configuration, passes the plan to the plan handler, evaluates
+// the policy against the plan and applies the saved plan when no violation is found
+//
+//	@receiver ter
+//	@param ctx
+//	@return error
+func (ter *Terraform) Apply(ctx context.Context) error {
+	planFile, err := ter.planToFile(ctx)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(planFile)
+	return ter.applyPlan(ctx, planFile, "apply")
+}
+
+// GenerateConfig plans the import blocks of the configuration and writes the configuration
+// terraform generates for the imported resources into out, which must not exist yet
+//
+//	@receiver ter
+//	@param ctx
+//	@param out
+//	@return *tfjson.Plan
+//	@return error
+func (ter *Terraform) GenerateConfig(ctx context.Context, out string) (*tfjson.Plan, error) {
+	planFile, err := ter.generateConfigPlan(ctx, out)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(planFile)
+	return ter.showPlan(ctx, planFile)
+}
+
+// Drift runs a refresh-only plan, whose resource drift lists the changes made outside of terraform
+//
+//	@receiver ter
+//	@param ctx
+//	@return *tfjson.Plan
+//	@return error
+func (ter *Terraform) Drift(ctx context.Context) (*tfjson.Plan, error) {
+	planFile, err := ter.planToFile(ctx, tfexec.RefreshOnly(true))
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(planFile)
+	return ter.showPlan(ctx, planFile)
+}
+
+// Destroy plans the destruction of the resources at the target addresses, e.g. aws_s3_bucket.logs
+// or module.network, and applies it through the same plan handler and policy as Apply
+//
+//	@receiver ter
+//	@param ctx
+//	@param targets
+//	@return error
+func (ter *Terraform) Destroy(ctx context.Context, targets []string) error {
+	if len(targets) == 0 {
+		return errors.New("destroy needs at least one target")
+	}
+	opts := []tfexec.PlanOption{tfexec.Destroy(true)}
+	for _, target := range targets {
+		opts = append(opts, tfexec.Target(target))
+	}
+	planFile, err := ter.planToFile(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(planFile)
+	return ter.applyPlan(ctx, planFile, "destroy")
+}
+
+// StateRm removes the resources at the addresses from the state, the infrastructure is left as it is
+//
+//	@receiver ter
+//	@param ctx
+//	@param addresses
+//	@return error
+func (ter *Terraform) StateRm(ctx context.Context, addresses []string) error {
+	log, err := ter.startOp("state rm")
+	if err != nil {
+		return err
+	}
+	for _, address := range addresses {
+		if err = ter.Exec.StateRm(ctx, address); err != nil {
+			break
+		}
+	}
+	return log.done(ctx, "state rm", err)
+}
+
+// State reads the current state, without refreshing it
+//
+//	@receiver ter
+//	@param ctx
+//	@return *tfjson.State
+//	@return error
+func (ter *Terraform) State(ctx context.Context) (*tfjson.State, error) {
+	state, err := ter.Exec.Show(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error showing state:%w", err)
+	}
+	return state, nil
+}
+
+// Validate runs terraform validate in the initialized working dir
+//
+//	@receiver ter
+//	@param ctx
+//	@return *tfjson.ValidateOutput
+//	@return error
+func (ter *Terraform) Validate(ctx context.Context) (*tfjson.ValidateOutput, error) {
+	out, err := ter.Exec.Validate(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error running terraform validate:%w", err)
+	}
+	return out, nil
+}
 
-	err := ter.Exec.Init(context.Background())
+// ProviderSchemas returns the schemas of the providers of the initialized working dir
+//
+//	@receiver ter
+//	@param ctx
+//	@return *tfjson.ProviderSchemas
+//	@return error
+func (ter *Terraform) ProviderSchemas(ctx context.Context) (*tfjson.ProviderSchemas, error) {
+	schemas, err := ter.Exec.ProvidersSchema(ctx)
 	if err != nil {
-		spin.Stop()
-		return fmt.Errorf("error running init:%w", err)
+		return nil, fmt.Errorf("error reading provider schemas:%w", err)
 	}
-	spin.Stop()
-	return nil
+	return schemas, nil
 }
 
-// Apply applies the Terraform configuration
+// applyPlan passes the saved plan to the plan handler, evaluates the policy against it and
+// applies it when no violation is found
 //
 //	@receiver ter
+//	@param ctx
+//	@param planFile
+//	@param op the name of the operation in the logs, apply or destroy
 //	@return error
-func (ter *Terraform) Apply() error {
-	spin := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
-	spin.Start()
+func (ter *Terraform) applyPlan(ctx context.Context, planFile string, op string) error {
+	if ter.Policy != nil || ter.PlanHandler != nil {
+		plan, err := ter.showPlan(ctx, planFile)
+		if err != nil {
+			return err
+		}
+		if ter.PlanHandler != nil {
+			if err = ter.PlanHandler(plan); err != nil {
+				return err
+			}
+		}
+		if ter.Policy != nil {
+			violations, err := ter.Policy.Evaluate(plan)
+			if err != nil {
+				return fmt.Errorf("error evaluating policy:%w", err)
+			}
+			if len(violations) > 0 {
+				return errors.Wrapf(errPolicy, "%s blocked by %d policy violation(s):\n%s", op, len(violations), formatViolations(violations))
+			}
+		}
+	}
 
-	err := ter.Exec.Apply(context.Background())
+	ctx, cancel := withTimeout(ctx, ter.Timeouts.Apply)
+	defer cancel()
+	log, err := ter.startOp(op)
 	if err != nil {
-		spin.Stop()
-		return fmt.Errorf("error running apply:%w", err)
+		return err
+	}
+	if log.events != nil {
+		return log.done(ctx, op, ter.Exec.ApplyJSON(ctx, log.json, tfexec.DirOrPlan(planFile)))
+	}
+	return log.done(ctx, op, ter.Exec.Apply(ctx, tfexec.DirOrPlan(planFile)))
+}
+
+// planToFile runs terraform plan and saves the plan into a temporary file
+//
+//	@receiver ter
+//	@param ctx
+//	@param opts
+//	@return string
+//	@return error
+func (ter *Terraform) planToFile(ctx context.Context, opts ...tfexec.PlanOption) (string, error) {
+	f, err := os.CreateTemp("", "terraform-assistant-*.tfplan")
+	if err != nil {
+		return "", fmt.Errorf("error creating plan file:%w", err)
+	}
+	planFile := f.Name()
+	f.Close()
+
+	ctx, cancel := withTimeout(ctx, ter.Timeouts.Plan)
+	defer cancel()
+	log, err := ter.startOp("plan")
+	if err != nil {
+		os.Remove(planFile)
+		return "", err
+	}
+	opts = ter.planOptions(append(opts, tfexec.Out(planFile))...)
+	if log.events != nil {
+		_, err = ter.Exec.PlanJSON(ctx, log.json, opts...)
+	} else {
+		_, err = ter.Exec.Plan(ctx, opts...)
+	}
+	if err = log.done(ctx, "plan", err); err != nil {
+		os.Remove(planFile)
+		return "", err
+	}
+	return planFile, nil
+}
+
+// generateConfigPlan runs terraform plan -generate-config-out and saves the plan into a temporary file.
+// terraform-exec only passes -generate-config-out to plan from v0.25, which needs a newer go, so the
+// command is run directly with the output, log and timeout of planToFile.
+//
+//	@receiver ter
+//	@param ctx
+//	@param out
+//	@return string
+//	@return error
+func (ter *Terraform) generateConfigPlan(ctx context.Context, out string) (string, error) {
+	f, err := os.CreateTemp("", "terraform-assistant-*.tfplan")
+	if err != nil {
+		return "", fmt.Errorf("error creating plan file:%w", err)
+	}
+	planFile := f.Name()
+	f.Close()
+
+	ctx, cancel := withTimeout(ctx, ter.Timeouts.Plan)
+	defer cancel()
+	log, err := ter.startOp("plan")
+	if err != nil {
+		os.Remove(planFile)
+		return "", err
+	}
+	args := []string{"plan", "-input=false", "-generate-config-out=" + out, "-out=" + planFile}
+	for _, v := range ter.Vars {
+		args = append(args, "-var", v)
+	}
+	for _, f := range ter.VarFiles {
+		args = append(args, "-var-file="+f)
+	}
+	cmd := ter.command(ctx, log, args...)
+	if log.events != nil {
+		cmd.Args = append(cmd.Args, "-json")
+		cmd.Stdout = log.json
+	} else {
+		cmd.Args = append(cmd.Args, "-no-color")
+	}
+	if err = log.done(ctx, "plan", cmd.Run()); err != nil {
+		os.Remove(planFile)
+		return "", err
+	}
+	return planFile, nil
+}
+
+// command returns terraform with the arguments, writing to the output of the operation, for the
+// flags terraform-exec does not pass. Like terraform-exec, an interrupt lets terraform stop cleanly.
+//
+//	@receiver ter
+//	@param ctx
+//	@param log
+//	@param args
+//	@return *exec.Cmd
+func (ter *Terraform) command(ctx context.Context, log *opLog, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, ter.Exec.ExecPath(), args...)
+	cmd.Dir = ter.WorkingDir
+	cmd.Env = append(os.Environ(), "TF_IN_AUTOMATION=1")
+	cmd.Stdout, cmd.Stderr = log.stdout, log.stderr
+	if runtime.GOOS != "windows" {
+		cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+		cmd.WaitDelay = ter.interruptGrace
+	}
+	return cmd
+}
+
+// planOptions appends the variables and variable files to the plan options
+//
+//	@receiver ter
+//	@param opts
+//	@return []tfexec.PlanOption
+func (ter *Terraform) planOptions(opts ...tfexec.PlanOption) []tfexec.PlanOption {
+	for _, v := range ter.Vars {
+		opts = append(opts, tfexec.Var(v))
+	}
+	for _, f := range ter.VarFiles {
+		opts = append(opts, tfexec.VarFile(f))
+	}
+	return opts
+}
+
+// showPlan reads a saved plan file as JSON
+//
+//	@receiver ter
+//	@param ctx
+//	@param planFile
+//	@return *tfjson.Plan
+//	@return error
+func (ter *Terraform) showPlan(ctx context.Context, planFile string) (*tfjson.Plan, error) {
+	plan, err := ter.Exec.ShowPlanFile(ctx, planFile)
+	if err != nil {
+		return nil, fmt.Errorf("error showing plan:%w", err)
+	}
+	return plan, nil
+}
+
+// withTimeout returns a context cancelled after the timeout, or the parent context when it is zero
+//
+//	@param ctx
+//	@param timeout
+//	@return context.Context
+//	@return context.CancelFunc
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
 	}
-	spin.Stop()
-	return nil
+	return context.WithTimeout(ctx, timeout)
 }
@@ -0,0 +1,277 @@
+package terraform
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/pkg/errors"
+)
+
+// fakeTerraform records its arguments in the calls file next to it, writes the plan file of plan,
+// prints a change summary event for plan and apply with -json and the plan of plan.json for show
+const fakeTerraform = `#!/bin/sh
+dir=$(dirname "$0")
+echo "$@" >> "$dir/calls"
+case "$1 $*" in
+plan*-json*|apply*-json*)
+  echo '{"@level":"info","@message":"'"$1"' summary","type":"change_summary","changes":{"add":0,"change":0,"remove":1,"operation":"'"$1"'"}}' ;;
+esac
+case "$1" in
+version)
+  echo '{"terraform_version":"1.9.0","platform":"linux_amd64","provider_selections":{},"terraform_outdated":false}' ;;
+plan)
+  for arg in "$@"; do
+    case "$arg" in -out=*) : > "${arg#-out=}" ;; esac
+  done ;;
+show)
+  cat "$dir/plan.json" ;;
+esac
+`
+
+// destroyPlan is the plan shown for the destroy of aws_s3_bucket.logs
+const destroyPlan = `{
+  "format_version": "1.2",
+  "resource_changes": [
+    {
+      "address": "aws_s3_bucket.logs",
+      "type": "aws_s3_bucket",
+      "name": "logs",
+      "change": {"actions": ["delete"], "before": {"bucket": "logs"}, "after": null}
+    }
+  ]
+}`
+
+// newFakeTerraform returns a Terraform running the fake terraform and the path of its calls file
+func newFakeTerraform(t *testing.T, options ...Option) (*Terraform, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake terraform is a shell script")
+	}
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "terraform"), []byte(fakeTerraform), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(bin, "plan.json"), []byte(destroyPlan), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ter, err := NewTerraform(t.TempDir(), filepath.Join(bin, "terraform"), options...)
+	if err != nil {
+		t.Fatalf("NewTerraform() error = %v", err)
+	}
+	return ter, filepath.Join(bin, "calls")
+}
+
+// commands returns the terraform subcommands that were run, without version
+func commands(t *testing.T, calls string) []string {
+	t.Helper()
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if !strings.HasPrefix(line, "version") {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+func TestDestroy(t *testing.T) {
+	denyDelete := writePolicies(t, map[string]string{"no_delete.cel": "'delete' in resource.change.actions"})
+	allowAll := writePolicies(t, map[string]string{"allow.cel": "false"})
+	errHandler := errors.New("declined")
+	tests := []struct {
+		name      string
+		policy    string
+		handler   error
+		wantErr   error
+		wantApply bool
+	}{
+		{
+			name:      "no policy",
+			wantApply: true,
+		},
+		{
+			name:      "allowed by the policy",
+			policy:    allowAll,
+			wantApply: true,
+		},
+		{
+			name:    "blocked by the policy",
+			policy:  denyDelete,
+			wantErr: errPolicy,
+		},
+		{
+			name:    "declined by the plan handler",
+			policy:  allowAll,
+			handler: errHandler,
+			wantErr: errHandler,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var handled *tfjson.Plan
+			options := []Option{WithPlanHandler(func(plan *tfjson.Plan) error {
+				handled = plan
+				return tt.handler
+			})}
+			if tt.policy != "" {
+				policy, err := LoadPolicies([]string{tt.policy})
+				if err != nil {
+					t.Fatal(err)
+				}
+				options = append(options, WithPolicy(policy))
+			}
+			ter, calls := newFakeTerraform(t, options...)
+
+			err := ter.Destroy(context.Background(), []string{"aws_s3_bucket.logs"})
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Destroy() error = %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Destroy() error = %v, want %v", err, tt.wantErr)
+			}
+			if handled == nil || len(handled.ResourceChanges) != 1 || !handled.ResourceChanges[0].Change.Actions.Delete() {
+				t.Errorf("plan handler got %v, want the destroy plan", handled)
+			}
+
+			cmds := commands(t, calls)
+			if len(cmds) == 0 || !strings.HasPrefix(cmds[0], "plan ") || !strings.Contains(cmds[0], "-destroy") || !strings.Contains(cmds[0], "-target=aws_s3_bucket.logs") {
+				t.Fatalf("first command = %v, want a targeted destroy plan", cmds)
+			}
+			applied := strings.HasPrefix(cmds[len(cmds)-1], "apply ")
+			if applied != tt.wantApply {
+				t.Errorf("commands = %v, applied = %v, want %v", cmds, applied, tt.wantApply)
+			}
+			if applied && strings.Contains(cmds[len(cmds)-1], "-destroy") {
+				t.Errorf("apply = %q, want the saved plan to be applied", cmds[len(cmds)-1])
+			}
+		})
+	}
+}
+
+func TestDestroyWithoutTargets(t *testing.T) {
+	ter, calls := newFakeTerraform(t)
+	if err := ter.Destroy(context.Background(), nil); err == nil {
+		t.Fatal("Destroy() without targets succeeded")
+	}
+	if _, err := os.Stat(calls); !os.IsNotExist(err) {
+		t.Errorf("terraform was run without targets: %v", err)
+	}
+}
+
+func TestInitMigrateState(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          InitOptions
+		wantForceCopy bool
+	}{
+		{name: "init", opts: InitOptions{BackendConfigs: []string{"path=new.tfstate"}}},
+		{name: "migrate state", opts: InitOptions{MigrateState: true, BackendConfigs: []string{"path=new.tfstate"}}, wantForceCopy: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ter, calls := newFakeTerraform(t)
+			if err := ter.Init(context.Background(), tt.opts); err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+			cmds := commands(t, calls)
+			if len(cmds) != 1 || !strings.HasPrefix(cmds[0], "init ") || !strings.Contains(cmds[0], "-input=false") {
+				t.Fatalf("commands = %v, want a single init", cmds)
+			}
+			if got := strings.Contains(cmds[0], "-force-copy"); got != tt.wantForceCopy {
+				t.Errorf("init = %q, -force-copy = %v, want %v", cmds[0], got, tt.wantForceCopy)
+			}
+		})
+	}
+}
+
+func TestInitMigrateStateLocal(t *testing.T) {
+	execPath, err := exec.LookPath("terraform")
+	if err != nil {
+		t.Skip("terraform is not installed")
+	}
+	dir := t.TempDir()
+	writeBackend := func(path string) {
+		t.Helper()
+		backend := "terraform {\n  backend \"local\" {\n    path = \"" + path + "\"\n  }\n}\n"
+		if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(backend), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeBackend("old.tfstate")
+	ter, err := NewTerraform(dir, execPath)
+	if err != nil {
+		t.Fatalf("NewTerraform() error = %v", err)
+	}
+	ctx := context.Background()
+	if err = ter.Init(ctx, InitOptions{}); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	// an output makes the state worth migrating
+	state := `{"version":4,"terraform_version":"1.5.0","serial":1,"lineage":"migrate-test","outputs":{"name":{"value":"x","type":"string"}},"resources":[]}`
+	if err = os.WriteFile(filepath.Join(dir, "old.tfstate"), []byte(state), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	writeBackend("new.tfstate")
+	if err = ter.Init(ctx, InitOptions{MigrateState: true}); err != nil {
+		t.Fatalf("Init() with migrate state error = %v", err)
+	}
+	migrated, err := os.ReadFile(filepath.Join(dir, "new.tfstate"))
+	if err != nil {
+		t.Fatalf("the state was not migrated: %v", err)
+	}
+	if !strings.Contains(string(migrated), `"migrate-test"`) {
+		t.Errorf("new.tfstate = %s, want the lineage of the old state", migrated)
+	}
+}
+
+func TestStateRm(t *testing.T) {
+	ter, calls := newFakeTerraform(t)
+	addresses := []string{"aws_s3_bucket.logs", "aws_s3_bucket.data"}
+	if err := ter.StateRm(context.Background(), addresses); err != nil {
+		t.Fatalf("StateRm() error = %v", err)
+	}
+	cmds := commands(t, calls)
+	if len(cmds) != len(addresses) {
+		t.Fatalf("commands = %v, want one state rm per address", cmds)
+	}
+	for i, address := range addresses {
+		if !strings.HasPrefix(cmds[i], "state rm ") || !strings.HasSuffix(cmds[i], " "+address) {
+			t.Errorf("command %d = %q, want state rm of %s", i, cmds[i], address)
+		}
+	}
+}
+
+func TestEventsInRunLog(t *testing.T) {
+	var operations []string
+	ter, _ := newFakeTerraform(t, WithEvents(EventHandlerFunc(func(event Event) {
+		if event.Type == EventChangeSummary {
+			operations = append(operations, event.Changes.Operation)
+		}
+	})))
+	if err := ter.Destroy(context.Background(), []string{"aws_s3_bucket.logs"}); err != nil {
+		t.Fatalf("Destroy() error = %v", err)
+	}
+	if want := []string{"plan", "apply"}; !slices.Equal(operations, want) {
+		t.Errorf("handled change summaries of %v, want %v", operations, want)
+	}
+	data, err := os.ReadFile(ter.LogPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, op := range []string{"plan", "apply"} {
+		if !strings.Contains(string(data), `"@message":"`+op+` summary"`) {
+			t.Errorf("run log = %s, want the %s events", data, op)
+		}
+	}
+}
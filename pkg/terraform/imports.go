@@ -0,0 +1,63 @@
+package terraform
+
+import (
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/pkg/errors"
+)
+
+var errImport = errors.New("invalid import blocks")
+
+// Import is an import block bringing an existing resource under management
+type Import struct {
+	// To is the resource address, e.g. aws_s3_bucket.logs
+	To string
+	ID string
+}
+
+// CheckImports parses a template that must only hold import blocks, each with a resource
+// address in `to` and a literal `id`, and returns them
+//
+//	@param src
+//	@return []Import
+//	@return error
+func CheckImports(src string) ([]Import, error) {
+	file, diags := hclsyntax.ParseConfig([]byte(src), "imports.tf", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, errors.Wrapf(errImport, "error parsing import blocks: %s", diags.Error())
+	}
+	body := file.Body.(*hclsyntax.Body)
+	if len(body.Attributes) > 0 {
+		return nil, errors.Wrap(errImport, "only import blocks are allowed")
+	}
+	var imports []Import
+	seen := map[string]bool{}
+	for _, block := range body.Blocks {
+		if block.Type != "import" {
+			return nil, errors.Wrapf(errImport, "unexpected %s block, only import blocks are allowed", block.Type)
+		}
+		attr, ok := block.Body.Attributes["to"]
+		if !ok {
+			return nil, errors.Wrap(errImport, "import block without to")
+		}
+		to := strings.TrimSpace(string(attr.Expr.Range().SliceBytes([]byte(src))))
+		if parts := strings.Split(strings.SplitN(to, "[", 2)[0], "."); len(parts) < 2 || parts[0] == "data" || parts[0] == "var" || parts[0] == "local" {
+			return nil, errors.Wrapf(errImport, "%q is not a resource address", to)
+		}
+		id, ok := attrString(block.Body, "id")
+		if !ok || id == "" {
+			return nil, errors.Wrapf(errImport, "import of %s has no id", to)
+		}
+		if seen[to] {
+			return nil, errors.Wrapf(errImport, "%s is imported twice", to)
+		}
+		seen[to] = true
+		imports = append(imports, Import{To: to, ID: id})
+	}
+	if len(imports) == 0 {
+		return nil, errors.Wrap(errImport, "no import blocks found")
+	}
+	return imports, nil
+}
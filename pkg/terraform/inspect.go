@@ -0,0 +1,126 @@
+package terraform
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/pkg/errors"
+)
+
+// maxSchemaDescription is the length descriptions are shortened to in a formatted schema
+const maxSchemaDescription = 120
+
+var errSchema = errors.New("unknown resource type")
+
+// StateAddresses returns the addresses of the resources and data sources of the state, child modules included
+//
+//	@param state
+//	@return []string
+func StateAddresses(state *tfjson.State) []string {
+	if state == nil || state.Values == nil {
+		return nil
+	}
+	var addresses []string
+	var walk func(m *tfjson.StateModule)
+	walk = func(m *tfjson.StateModule) {
+		if m == nil {
+			return
+		}
+		for _, r := range m.Resources {
+			addresses = append(addresses, r.Address)
+		}
+		for _, child := range m.ChildModules {
+			walk(child)
+		}
+	}
+	walk(state.Values.RootModule)
+	sort.Strings(addresses)
+	return addresses
+}
+
+// FormatResourceSchema renders the schema of a resource type of the providers: every attribute with
+// its type and whether it is required, optional or computed, and the nested blocks
+//
+//	@param schemas
+//	@param resourceType
+//	@return string
+//	@return error
+func FormatResourceSchema(schemas *tfjson.ProviderSchemas, resourceType string) (string, error) {
+	if schemas != nil {
+		for source, provider := range schemas.Schemas {
+			if schema, ok := provider.ResourceSchemas[resourceType]; ok && schema.Block != nil {
+				var b strings.Builder
+				fmt.Fprintf(&b, "resource %s (%s)\n", resourceType, source)
+				formatSchemaBlock(&b, schema.Block, "  ")
+				return b.String(), nil
+			}
+		}
+	}
+	return "", errors.Wrapf(errSchema, "no provider of the working dir has resource type %q, run terraform init after adding its provider", resourceType)
+}
+
+// formatSchemaBlock writes the attributes and nested blocks of a block, sorted by name
+//
+//	@param b
+//	@param block
+//	@param indent
+func formatSchemaBlock(b *strings.Builder, block *tfjson.SchemaBlock, indent string) {
+	names := make([]string, 0, len(block.Attributes))
+	for name := range block.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		attr := block.Attributes[name]
+		kind := "nested"
+		if attr.AttributeNestedType == nil {
+			kind = attr.AttributeType.FriendlyName()
+		}
+		var flags []string
+		for flag, set := range map[string]bool{"required": attr.Required, "optional": attr.Optional, "computed": attr.Computed, "sensitive": attr.Sensitive, "deprecated": attr.Deprecated} {
+			if set {
+				flags = append(flags, flag)
+			}
+		}
+		sort.Strings(flags)
+		fmt.Fprintf(b, "%s%s (%s, %s)", indent, name, kind, strings.Join(flags, ", "))
+		if description := shorten(attr.Description); description != "" {
+			fmt.Fprintf(b, ": %s", description)
+		}
+		b.WriteString("\n")
+	}
+
+	names = names[:0]
+	for name := range block.NestedBlocks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		nested := block.NestedBlocks[name]
+		fmt.Fprintf(b, "%sblock %s (%s", indent, name, nested.NestingMode)
+		if nested.MinItems > 0 {
+			fmt.Fprintf(b, ", min %d", nested.MinItems)
+		}
+		if nested.MaxItems > 0 {
+			fmt.Fprintf(b, ", max %d", nested.MaxItems)
+		}
+		b.WriteString("):\n")
+		if nested.Block != nil {
+			formatSchemaBlock(b, nested.Block, indent+"  ")
+		}
+	}
+}
+
+// shorten returns the first line of a description, cut at maxSchemaDescription characters
+//
+//	@param description
+//	@return string
+func shorten(description string) string {
+	description, _, _ = strings.Cut(strings.TrimSpace(description), "\n")
+	if len(description) > maxSchemaDescription {
+		description = description[:maxSchemaDescription-3] + "..."
+	}
+	return description
+}
@@ -0,0 +1,238 @@
+package terraform
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/pkg/errors"
+)
+
+// Severity is the severity of a lint finding
+type Severity int
+
+const (
+	SeverityLow Severity = iota + 1
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
+var errSeverity = errors.New("invalid severity")
+
+// suppressRegex matches comments like `# terraform-assistant:ignore TFA001,TFA002`, rules are
+// suppressed one by one, there is no wildcard
+var suppressRegex = regexp.MustCompile(`(?:#|//)\s*terraform-assistant:ignore\s+([A-Za-z0-9_,\s]+)`)
+
+// String returns the upper case name of the severity
+//
+//	@receiver s
+//	@return string
+func (s Severity) String() string {
+	switch s {
+	case SeverityLow:
+		return "LOW"
+	case SeverityMedium:
+		return "MEDIUM"
+	case SeverityHigh:
+		return "HIGH"
+	case SeverityCritical:
+		return "CRITICAL"
+	}
+	return "UNKNOWN"
+}
+
+// MarshalText encodes the severity as its lower case name
+//
+//	@receiver s
+//	@return []byte
+//	@return error
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(strings.ToLower(s.String())), nil
+}
+
+// ParseSeverity converts a severity name into a Severity, "none" returns 0
+//
+//	@param s
+//	@return Severity
+//	@return error
+func ParseSeverity(s string) (Severity, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "none", "":
+		return 0, nil
+	case "low":
+		return SeverityLow, nil
+	case "medium":
+		return SeverityMedium, nil
+	case "high":
+		return SeverityHigh, nil
+	case "critical":
+		return SeverityCritical, nil
+	}
+	return 0, errors.Wrapf(errSeverity, "unknown severity %q", s)
+}
+
+// Finding is a single rule violation found in a template
+type Finding struct {
+	RuleID     string
+	Severity   Severity
+	Resource   string
+	Message    string
+	Range      hcl.Range
+	Suppressed bool
+}
+
+// String formats the finding for the terminal
+//
+//	@receiver f
+//	@return string
+func (f Finding) String() string {
+	text := fmt.Sprintf("[%s] %s %s (%s:%d): %s", f.Severity, f.RuleID, f.Resource, f.Range.Filename, f.Range.Start.Line, f.Message)
+	if f.Suppressed {
+		text += " (suppressed)"
+	}
+	return text
+}
+
+// Rule is a lint rule that runs against every top level block of a template
+type Rule struct {
+	ID          string
+	Severity    Severity
+	Description string
+	// Check returns the violations found in the block
+	Check func(block *hclsyntax.Block) []violation
+}
+
+// violation is a message and the location it applies to
+type violation struct {
+	message string
+	rng     hcl.Range
+}
+
+// Lint parses the template and runs all the built-in rules against it
+//
+//	@param filename
+//	@param src
+//	@return []Finding
+//	@return error
+func Lint(filename string, src []byte) ([]Finding, error) {
+	return LintWithRules(filename, src, Rules())
+}
+
+// LintWithRules parses the template and runs the given rules against it
+//
+//	@param filename
+//	@param src
+//	@param rules
+//	@return []Finding
+//	@return error
+func LintWithRules(filename string, src []byte, rules []Rule) ([]Finding, error) {
+	file, diags := hclsyntax.ParseConfig(src, filename, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, errors.Wrapf(errTemplate, "error parsing %s: %s", filename, diags.Error())
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, errors.Wrapf(errTemplate, "unexpected body type in %s", filename)
+	}
+	lines := strings.Split(string(src), "\n")
+	var findings []Finding
+	for _, block := range body.Blocks {
+		suppressed := suppressedRules(lines, block.Range())
+		for _, rule := range rules {
+			for _, v := range rule.Check(block) {
+				findings = append(findings, Finding{
+					RuleID:     rule.ID,
+					Severity:   rule.Severity,
+					Resource:   blockAddress(block),
+					Message:    v.message,
+					Range:      v.rng,
+					Suppressed: suppressed[rule.ID],
+				})
+			}
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Severity > findings[j].Severity
+	})
+	return findings, nil
+}
+
+// Blocking returns the findings that are not suppressed and are at least as severe as the threshold
+//
+//	@param findings
+//	@param threshold
+//	@return []Finding
+func Blocking(findings []Finding, threshold Severity) []Finding {
+	if threshold == 0 {
+		return nil
+	}
+	var blocking []Finding
+	for _, f := range findings {
+		if !f.Suppressed && f.Severity >= threshold {
+			blocking = append(blocking, f)
+		}
+	}
+	return blocking
+}
+
+// SuppressedBlocking returns the suppressed findings that would block without their ignore comment.
+// Generated templates can carry ignore comments written by the model, so these need a review.
+//
+//	@param findings
+//	@param threshold
+//	@return []Finding
+func SuppressedBlocking(findings []Finding, threshold Severity) []Finding {
+	if threshold == 0 {
+		return nil
+	}
+	var suppressed []Finding
+	for _, f := range findings {
+		if f.Suppressed && f.Severity >= threshold {
+			suppressed = append(suppressed, f)
+		}
+	}
+	return suppressed
+}
+
+// suppressedRules collects the rule ids suppressed on the line above the block or inside it
+//
+//	@param lines
+//	@param rng
+//	@return map[string]bool
+func suppressedRules(lines []string, rng hcl.Range) map[string]bool {
+	suppressed := map[string]bool{}
+	start := rng.Start.Line - 2
+	if start < 0 {
+		start = 0
+	}
+	for i := start; i < rng.End.Line && i < len(lines); i++ {
+		match := suppressRegex.FindStringSubmatch(lines[i])
+		if match == nil {
+			continue
+		}
+		for _, id := range strings.FieldsFunc(match[1], func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+			suppressed[strings.ToUpper(id)] = true
+		}
+	}
+	return suppressed
+}
+
+// blockAddress returns the terraform address of a block, e.g. aws_instance.web or data.aws_ami.ubuntu
+//
+//	@param block
+//	@return string
+func blockAddress(block *hclsyntax.Block) string {
+	switch {
+	case block.Type == "resource" && len(block.Labels) == 2:
+		return block.Labels[0] + "." + block.Labels[1]
+	case block.Type == "data" && len(block.Labels) == 2:
+		return "data." + block.Labels[0] + "." + block.Labels[1]
+	case len(block.Labels) > 0:
+		return block.Type + "." + strings.Join(block.Labels, ".")
+	}
+	return block.Type
+}
@@ -0,0 +1,646 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+var (
+	// adminPorts are the ports that should never be reachable from the internet, sorted so
+	// the findings come out in the same order on every run
+	adminPorts = []adminPort{{22, "SSH"}, {3389, "RDP"}}
+
+	// publicCIDRs are the source ranges meaning "anyone"
+	publicCIDRs = map[string]bool{"0.0.0.0/0": true, "::/0": true, "*": true, "internet": true, "any": true}
+
+	// publicACLs are the canned S3 ACLs that expose a bucket
+	publicACLs = map[string]bool{"public-read": true, "public-read-write": true}
+
+	// lintEvalContext allows literal values wrapped in jsonencode to be evaluated
+	lintEvalContext = &hcl.EvalContext{
+		Functions: map[string]function.Function{
+			"jsonencode": stdlib.JSONEncodeFunc,
+		},
+	}
+)
+
+// adminPort is a port of a remote administration protocol
+type adminPort struct {
+	port int64
+	name string
+}
+
+// Rules returns the built-in security lint rules
+//
+//	@return []Rule
+func Rules() []Rule {
+	return []Rule{
+		{
+			ID:          "TFA001",
+			Severity:    SeverityCritical,
+			Description: "SSH or RDP open to the internet",
+			Check:       checkOpenAdminPorts,
+		},
+		{
+			ID:          "TFA002",
+			Severity:    SeverityCritical,
+			Description: "Storage bucket or container is publicly accessible",
+			Check:       checkPublicBuckets,
+		},
+		{
+			ID:          "TFA003",
+			Severity:    SeverityHigh,
+			Description: "Storage or disk is not encrypted at rest",
+			Check:       checkUnencryptedStorage,
+		},
+		{
+			ID:          "TFA004",
+			Severity:    SeverityCritical,
+			Description: "IAM policy grants all actions on all resources",
+			Check:       checkWildcardIAM,
+		},
+		{
+			ID:          "TFA005",
+			Severity:    SeverityCritical,
+			Description: "Database is publicly accessible",
+			Check:       checkPublicDatabases,
+		},
+		{
+			ID:          "TFA006",
+			Severity:    SeverityMedium,
+			Description: "Deletion protection is not enabled",
+			Check:       checkDeletionProtection,
+		},
+	}
+}
+
+// checkOpenAdminPorts flags firewall rules that allow SSH/RDP from 0.0.0.0/0
+//
+//	@param block
+//	@return []violation
+func checkOpenAdminPorts(block *hclsyntax.Block) []violation {
+	var out []violation
+	switch resourceType(block) {
+	case "aws_security_group":
+		for _, ingress := range childBlocks(block.Body, "ingress") {
+			out = append(out, awsIngressViolations(ingress.Body, ingress.Range(), "cidr_blocks", "ipv6_cidr_blocks")...)
+		}
+	case "aws_security_group_rule":
+		if typ, _ := attrString(block.Body, "type"); typ == "ingress" {
+			out = append(out, awsIngressViolations(block.Body, block.Range(), "cidr_blocks", "ipv6_cidr_blocks")...)
+		}
+	case "aws_vpc_security_group_ingress_rule":
+		out = append(out, awsIngressViolations(block.Body, block.Range(), "cidr_ipv4", "cidr_ipv6")...)
+	case "azurerm_network_security_rule":
+		out = append(out, azureRuleViolations(block.Body, block.Range())...)
+	case "azurerm_network_security_group":
+		for _, rule := range childBlocks(block.Body, "security_rule") {
+			out = append(out, azureRuleViolations(rule.Body, rule.Range())...)
+		}
+	case "google_compute_firewall":
+		if dir, ok := attrString(block.Body, "direction"); ok && !strings.EqualFold(dir, "INGRESS") {
+			return nil
+		}
+		if !anyPublic(attrStrings(block.Body, "source_ranges")) {
+			return nil
+		}
+		for _, allow := range childBlocks(block.Body, "allow") {
+			protocol, _ := attrString(allow.Body, "protocol")
+			if protocol != "tcp" && protocol != "all" {
+				continue
+			}
+			ports := attrStrings(allow.Body, "ports")
+			for _, p := range adminPorts {
+				if len(ports) == 0 || anyPortSpecCovers(ports, p.port) {
+					out = append(out, violation{fmt.Sprintf("%s (port %d) is open to the internet", p.name, p.port), allow.Range()})
+				}
+			}
+		}
+	}
+	return out
+}
+
+// awsIngressViolations checks an AWS ingress definition for admin ports open to the internet
+//
+//	@param body
+//	@param rng
+//	@param cidrAttrs
+//	@return []violation
+func awsIngressViolations(body *hclsyntax.Body, rng hcl.Range, cidrAttrs ...string) []violation {
+	var cidrs []string
+	for _, name := range cidrAttrs {
+		cidrs = append(cidrs, attrStrings(body, name)...)
+	}
+	if !anyPublic(cidrs) {
+		return nil
+	}
+	protocol, _ := attrString(body, "protocol")
+	if protocol == "" {
+		protocol, _ = attrString(body, "ip_protocol")
+	}
+	from, fromOK := attrNumber(body, "from_port")
+	to, toOK := attrNumber(body, "to_port")
+	var out []violation
+	for _, p := range adminPorts {
+		allPorts := protocol == "-1" || strings.EqualFold(protocol, "all")
+		if allPorts || (fromOK && toOK && from <= p.port && p.port <= to) {
+			out = append(out, violation{fmt.Sprintf("%s (port %d) is open to the internet", p.name, p.port), rng})
+		}
+	}
+	return out
+}
+
+// azureRuleViolations checks an Azure network security rule for admin ports open to the internet
+//
+//	@param body
+//	@param rng
+//	@return []violation
+func azureRuleViolations(body *hclsyntax.Body, rng hcl.Range) []violation {
+	direction, _ := attrString(body, "direction")
+	access, _ := attrString(body, "access")
+	if !strings.EqualFold(direction, "Inbound") || !strings.EqualFold(access, "Allow") {
+		return nil
+	}
+	sources := append(attrStrings(body, "source_address_prefix"), attrStrings(body, "source_address_prefixes")...)
+	if !anyPublic(sources) {
+		return nil
+	}
+	ports := append(attrStrings(body, "destination_port_range"), attrStrings(body, "destination_port_ranges")...)
+	var out []violation
+	for _, p := range adminPorts {
+		if anyPortSpecCovers(ports, p.port) {
+			out = append(out, violation{fmt.Sprintf("%s (port %d) is open to the internet", p.name, p.port), rng})
+		}
+	}
+	return out
+}
+
+// checkPublicBuckets flags buckets, ACLs and containers that allow anonymous access
+//
+//	@param block
+//	@return []violation
+func checkPublicBuckets(block *hclsyntax.Block) []violation {
+	body := block.Body
+	switch resourceType(block) {
+	case "aws_s3_bucket", "aws_s3_bucket_acl":
+		if acl, ok := attrString(body, "acl"); ok && publicACLs[acl] {
+			return []violation{{fmt.Sprintf("bucket uses the public canned ACL %q", acl), attrRange(block, "acl")}}
+		}
+	case "aws_s3_bucket_public_access_block":
+		var out []violation
+		for _, name := range []string{"block_public_acls", "block_public_policy", "ignore_public_acls", "restrict_public_buckets"} {
+			if v, ok := attrBool(body, name); ok && !v {
+				out = append(out, violation{fmt.Sprintf("%s is disabled", name), attrRange(block, name)})
+			}
+		}
+		return out
+	case "aws_s3_bucket_policy":
+		if doc, ok := attrPolicyDocument(body, "policy"); ok {
+			for _, stmt := range doc.statements() {
+				if stmt.allows() && stmt.publicPrincipal() {
+					return []violation{{"bucket policy allows access to any principal", attrRange(block, "policy")}}
+				}
+			}
+		}
+	case "azurerm_storage_container":
+		if access, ok := attrString(body, "container_access_type"); ok && access != "private" {
+			return []violation{{fmt.Sprintf("container access type is %q", access), attrRange(block, "container_access_type")}}
+		}
+	case "azurerm_storage_account":
+		for _, name := range []string{"allow_nested_items_to_be_public", "allow_blob_public_access"} {
+			if v, ok := attrBool(body, name); ok && v {
+				return []violation{{fmt.Sprintf("%s is enabled", name), attrRange(block, name)}}
+			}
+		}
+	case "google_storage_bucket_iam_member", "google_storage_bucket_iam_binding":
+		members := append(attrStrings(body, "member"), attrStrings(body, "members")...)
+		for _, m := range members {
+			if m == "allUsers" || m == "allAuthenticatedUsers" {
+				return []violation{{fmt.Sprintf("bucket is shared with %s", m), block.Range()}}
+			}
+		}
+	}
+	return nil
+}
+
+// checkUnencryptedStorage flags volumes, databases and file systems without encryption at rest
+//
+//	@param block
+//	@return []violation
+func checkUnencryptedStorage(block *hclsyntax.Block) []violation {
+	body := block.Body
+	requireTrue := func(name string) []violation {
+		if v, ok := attrBool(body, name); !ok || !v {
+			return []violation{{fmt.Sprintf("%s is not set to true", name), attrRange(block, name)}}
+		}
+		return nil
+	}
+	switch resourceType(block) {
+	case "aws_ebs_volume", "aws_efs_file_system", "aws_redshift_cluster":
+		return requireTrue("encrypted")
+	case "aws_db_instance", "aws_rds_cluster", "aws_docdb_cluster", "aws_neptune_cluster":
+		return requireTrue("storage_encrypted")
+	case "aws_instance", "aws_launch_template":
+		var out []violation
+		for _, typ := range []string{"root_block_device", "ebs_block_device"} {
+			for _, dev := range childBlocks(body, typ) {
+				if v, ok := attrBool(dev.Body, "encrypted"); !ok || !v {
+					out = append(out, violation{fmt.Sprintf("%s is not encrypted", typ), dev.Range()})
+				}
+			}
+		}
+		return out
+	case "azurerm_managed_disk", "azurerm_linux_virtual_machine", "azurerm_windows_virtual_machine":
+		// a disk encryption set encrypts the disks with customer managed keys instead
+		_, diskSet := body.Attributes["disk_encryption_set_id"]
+		_, secureVMSet := body.Attributes["secure_vm_disk_encryption_set_id"]
+		if v, ok := attrBool(body, "encryption_at_host_enabled"); ok && !v && !diskSet && !secureVMSet {
+			return []violation{{"encryption_at_host_enabled is disabled", attrRange(block, "encryption_at_host_enabled")}}
+		}
+	case "azurerm_storage_account":
+		if v, ok := attrBool(body, "infrastructure_encryption_enabled"); ok && !v {
+			return []violation{{"infrastructure_encryption_enabled is disabled", attrRange(block, "infrastructure_encryption_enabled")}}
+		}
+	}
+	return nil
+}
+
+// checkWildcardIAM flags policies granting "*" actions on "*" resources
+//
+//	@param block
+//	@return []violation
+func checkWildcardIAM(block *hclsyntax.Block) []violation {
+	body := block.Body
+	switch resourceType(block) {
+	case "aws_iam_policy", "aws_iam_role_policy", "aws_iam_user_policy", "aws_iam_group_policy":
+		doc, ok := attrPolicyDocument(body, "policy")
+		if !ok {
+			return nil
+		}
+		for _, stmt := range doc.statements() {
+			if stmt.allows() && containsWildcard(stmt.list("Action")) && containsWildcard(stmt.list("Resource")) {
+				return []violation{{"policy allows \"*:*\"", attrRange(block, "policy")}}
+			}
+		}
+	case "aws_iam_policy_document":
+		var out []violation
+		for _, stmt := range childBlocks(body, "statement") {
+			if effect, ok := attrString(stmt.Body, "effect"); ok && effect == "Deny" {
+				continue
+			}
+			if containsWildcard(attrStrings(stmt.Body, "actions")) && containsWildcard(attrStrings(stmt.Body, "resources")) {
+				out = append(out, violation{"statement allows \"*:*\"", stmt.Range()})
+			}
+		}
+		return out
+	case "azurerm_role_definition":
+		for _, perm := range childBlocks(body, "permissions") {
+			if containsWildcard(attrStrings(perm.Body, "actions")) {
+				return []violation{{"role definition allows all actions", perm.Range()}}
+			}
+		}
+	}
+	return nil
+}
+
+// checkPublicDatabases flags databases reachable from the internet
+//
+//	@param block
+//	@return []violation
+func checkPublicDatabases(block *hclsyntax.Block) []violation {
+	body := block.Body
+	switch resourceType(block) {
+	case "aws_db_instance", "aws_rds_cluster_instance", "aws_redshift_cluster":
+		if v, ok := attrBool(body, "publicly_accessible"); ok && v {
+			return []violation{{"publicly_accessible is enabled", attrRange(block, "publicly_accessible")}}
+		}
+	case "azurerm_mssql_server", "azurerm_postgresql_server", "azurerm_postgresql_flexible_server",
+		"azurerm_mysql_server", "azurerm_mysql_flexible_server", "azurerm_cosmosdb_account":
+		if v, ok := attrBool(body, "public_network_access_enabled"); ok && v {
+			return []violation{{"public_network_access_enabled is enabled", attrRange(block, "public_network_access_enabled")}}
+		}
+	case "azurerm_mssql_firewall_rule", "azurerm_postgresql_firewall_rule", "azurerm_postgresql_flexible_server_firewall_rule",
+		"azurerm_mysql_firewall_rule", "azurerm_mysql_flexible_server_firewall_rule":
+		start, _ := attrString(body, "start_ip_address")
+		end, _ := attrString(body, "end_ip_address")
+		if start == "0.0.0.0" && end == "255.255.255.255" {
+			return []violation{{"firewall rule allows every IPv4 address", block.Range()}}
+		}
+	case "google_sql_database_instance":
+		for _, settings := range childBlocks(body, "settings") {
+			for _, ipConfig := range childBlocks(settings.Body, "ip_configuration") {
+				for _, network := range childBlocks(ipConfig.Body, "authorized_networks") {
+					if anyPublic(attrStrings(network.Body, "value")) {
+						return []violation{{"authorized network 0.0.0.0/0 exposes the instance", network.Range()}}
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkDeletionProtection flags stateful resources that can be destroyed without protection
+//
+//	@param block
+//	@return []violation
+func checkDeletionProtection(block *hclsyntax.Block) []violation {
+	body := block.Body
+	requireTrue := func(name string) []violation {
+		if v, ok := attrBool(body, name); !ok || !v {
+			return []violation{{fmt.Sprintf("%s is not enabled", name), attrRange(block, name)}}
+		}
+		return nil
+	}
+	switch resourceType(block) {
+	case "aws_db_instance", "aws_rds_cluster", "aws_docdb_cluster", "aws_neptune_cluster":
+		return requireTrue("deletion_protection")
+	case "aws_dynamodb_table":
+		return requireTrue("deletion_protection_enabled")
+	case "aws_lb", "aws_alb":
+		return requireTrue("enable_deletion_protection")
+	case "google_sql_database_instance":
+		if v, ok := attrBool(body, "deletion_protection"); ok && !v {
+			return []violation{{"deletion_protection is disabled", attrRange(block, "deletion_protection")}}
+		}
+	}
+	return nil
+}
+
+// resourceType returns the type label of a resource or data block, otherwise ""
+//
+//	@param block
+//	@return string
+func resourceType(block *hclsyntax.Block) string {
+	if (block.Type == "resource" || block.Type == "data") && len(block.Labels) > 0 {
+		return block.Labels[0]
+	}
+	return ""
+}
+
+// childBlocks returns the nested blocks of the given type
+//
+//	@param body
+//	@param typ
+//	@return []*hclsyntax.Block
+func childBlocks(body *hclsyntax.Body, typ string) []*hclsyntax.Block {
+	var blocks []*hclsyntax.Block
+	for _, b := range body.Blocks {
+		if b.Type == typ {
+			blocks = append(blocks, b)
+		}
+	}
+	return blocks
+}
+
+// attrRange returns the range of the attribute or the block when it is not set
+//
+//	@param block
+//	@param name
+//	@return hcl.Range
+func attrRange(block *hclsyntax.Block, name string) hcl.Range {
+	if attr, ok := block.Body.Attributes[name]; ok {
+		return attr.SrcRange
+	}
+	return block.DefRange()
+}
+
+// attrValue evaluates an attribute without variables, returning false when it is unknown
+//
+//	@param body
+//	@param name
+//	@return cty.Value
+//	@return bool
+func attrValue(body *hclsyntax.Body, name string) (cty.Value, bool) {
+	attr, ok := body.Attributes[name]
+	if !ok {
+		return cty.NilVal, false
+	}
+	v, diags := attr.Expr.Value(lintEvalContext)
+	if diags.HasErrors() || !v.IsWhollyKnown() || v.IsNull() {
+		return cty.NilVal, false
+	}
+	return v, true
+}
+
+// attrString returns the value of a string attribute
+//
+//	@param body
+//	@param name
+//	@return string
+//	@return bool
+func attrString(body *hclsyntax.Body, name string) (string, bool) {
+	v, ok := attrValue(body, name)
+	if !ok {
+		return "", false
+	}
+	switch v.Type() {
+	case cty.String:
+		return v.AsString(), true
+	case cty.Number:
+		return v.AsBigFloat().Text('f', -1), true
+	case cty.Bool:
+		return strconv.FormatBool(v.True()), true
+	}
+	return "", false
+}
+
+// attrBool returns the value of a bool attribute, accepting "true"/"false" strings
+//
+//	@param body
+//	@param name
+//	@return bool
+//	@return bool
+func attrBool(body *hclsyntax.Body, name string) (bool, bool) {
+	s, ok := attrString(body, name)
+	if !ok {
+		return false, false
+	}
+	b, err := strconv.ParseBool(s)
+	return b, err == nil
+}
+
+// attrNumber returns the value of a number attribute
+//
+//	@param body
+//	@param name
+//	@return int64
+//	@return bool
+func attrNumber(body *hclsyntax.Body, name string) (int64, bool) {
+	s, ok := attrString(body, name)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	return n, err == nil
+}
+
+// attrStrings returns a string or list of strings attribute as a slice
+//
+//	@param body
+//	@param name
+//	@return []string
+func attrStrings(body *hclsyntax.Body, name string) []string {
+	v, ok := attrValue(body, name)
+	if !ok {
+		return nil
+	}
+	if v.Type() == cty.String {
+		return []string{v.AsString()}
+	}
+	if !v.CanIterateElements() {
+		return nil
+	}
+	var out []string
+	for it := v.ElementIterator(); it.Next(); {
+		_, elem := it.Element()
+		if elem.IsKnown() && !elem.IsNull() && elem.Type() == cty.String {
+			out = append(out, elem.AsString())
+		}
+	}
+	return out
+}
+
+// anyPublic reports whether any of the source ranges means "the whole internet"
+//
+//	@param ranges
+//	@return bool
+func anyPublic(ranges []string) bool {
+	for _, r := range ranges {
+		if publicCIDRs[strings.ToLower(r)] {
+			return true
+		}
+	}
+	return false
+}
+
+// anyPortSpecCovers reports whether any port spec ("22", "20-30", "*") includes the port
+//
+//	@param specs
+//	@param port
+//	@return bool
+func anyPortSpecCovers(specs []string, port int64) bool {
+	for _, spec := range specs {
+		if spec == "*" {
+			return true
+		}
+		from, to, found := strings.Cut(spec, "-")
+		if !found {
+			to = from
+		}
+		lo, errLo := strconv.ParseInt(strings.TrimSpace(from), 10, 64)
+		hi, errHi := strconv.ParseInt(strings.TrimSpace(to), 10, 64)
+		if errLo == nil && errHi == nil && lo <= port && port <= hi {
+			return true
+		}
+	}
+	return false
+}
+
+// containsWildcard reports whether the list contains "*" or "*:*"
+//
+//	@param values
+//	@return bool
+func containsWildcard(values []string) bool {
+	for _, v := range values {
+		if v == "*" || v == "*:*" {
+			return true
+		}
+	}
+	return false
+}
+
+// policyDocument is a decoded IAM policy document
+type policyDocument map[string]interface{}
+
+// policyStatement is a single statement of an IAM policy document
+type policyStatement map[string]interface{}
+
+// attrPolicyDocument decodes a JSON policy given as a heredoc or jsonencode() call
+//
+//	@param body
+//	@param name
+//	@return policyDocument
+//	@return bool
+func attrPolicyDocument(body *hclsyntax.Body, name string) (policyDocument, bool) {
+	raw, ok := attrString(body, name)
+	if !ok {
+		return nil, false
+	}
+	var doc policyDocument
+	if err := json.Unmarshal([]byte(raw), &doc); err != nil {
+		return nil, false
+	}
+	return doc, true
+}
+
+// statements returns the statements of the document, which may be an object or a list
+//
+//	@receiver d
+//	@return []policyStatement
+func (d policyDocument) statements() []policyStatement {
+	var out []policyStatement
+	switch s := d["Statement"].(type) {
+	case map[string]interface{}:
+		out = append(out, s)
+	case []interface{}:
+		for _, item := range s {
+			if m, ok := item.(map[string]interface{}); ok {
+				out = append(out, m)
+			}
+		}
+	}
+	return out
+}
+
+// allows reports whether the statement has an Allow effect
+//
+//	@receiver s
+//	@return bool
+func (s policyStatement) allows() bool {
+	effect, _ := s["Effect"].(string)
+	return effect == "" || effect == "Allow"
+}
+
+// list returns a string or list of strings field of the statement
+//
+//	@receiver s
+//	@param key
+//	@return []string
+func (s policyStatement) list(key string) []string {
+	switch v := s[key].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var out []string
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				out = append(out, str)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// publicPrincipal reports whether the statement applies to "*" or {"AWS": "*"}
+//
+//	@receiver s
+//	@return bool
+func (s policyStatement) publicPrincipal() bool {
+	switch p := s["Principal"].(type) {
+	case string:
+		return p == "*"
+	case map[string]interface{}:
+		return containsWildcard(policyStatement(p).list("AWS"))
+	}
+	return false
+}
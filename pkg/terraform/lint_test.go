@@ -0,0 +1,150 @@
+package terraform
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLintRules(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{
+			name: "ssh and rdp open in the order of the ports",
+			src: `resource "aws_security_group" "web" {
+  ingress {
+    from_port   = 0
+    to_port     = 65535
+    protocol    = "tcp"
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+}`,
+			want: []string{"TFA001 SSH (port 22)", "TFA001 RDP (port 3389)"},
+		},
+		{
+			name: "ssh from a private range",
+			src: `resource "aws_security_group" "web" {
+  ingress {
+    from_port   = 22
+    to_port     = 22
+    protocol    = "tcp"
+    cidr_blocks = ["10.0.0.0/8"]
+  }
+}`,
+		},
+		{
+			name: "azure rule open to the internet",
+			src: `resource "azurerm_network_security_rule" "rdp" {
+  direction                  = "Inbound"
+  access                     = "Allow"
+  source_address_prefix      = "*"
+  destination_port_ranges    = ["22", "3389"]
+}`,
+			want: []string{"TFA001 SSH (port 22)", "TFA001 RDP (port 3389)"},
+		},
+		{
+			name: "host encryption disabled",
+			src: `resource "azurerm_linux_virtual_machine" "vm" {
+  encryption_at_host_enabled = false
+}`,
+			want: []string{"TFA003 encryption_at_host_enabled is disabled"},
+		},
+		{
+			name: "host encryption disabled with a disk encryption set",
+			src: `resource "azurerm_managed_disk" "data" {
+  encryption_at_host_enabled = false
+  disk_encryption_set_id     = azurerm_disk_encryption_set.cmk.id
+}`,
+		},
+		{
+			name: "host encryption disabled with a secure vm disk encryption set reference",
+			src: `resource "azurerm_windows_virtual_machine" "vm" {
+  encryption_at_host_enabled       = false
+  secure_vm_disk_encryption_set_id = azurerm_disk_encryption_set.cmk.id
+}`,
+		},
+		{
+			name: "unencrypted volume",
+			src: `resource "aws_ebs_volume" "data" {
+  size = 10
+}`,
+			want: []string{"TFA003 encrypted is not set to true"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings, err := Lint("main.tf", []byte(tt.src))
+			if err != nil {
+				t.Fatalf("Lint() error = %v", err)
+			}
+			if len(findings) != len(tt.want) {
+				t.Fatalf("Lint() = %v, want %v", findings, tt.want)
+			}
+			for i, f := range findings {
+				if got := f.RuleID + " " + f.Message; !strings.HasPrefix(got, tt.want[i]) {
+					t.Errorf("Lint()[%d] = %q, want prefix %q", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLintSuppression(t *testing.T) {
+	const volume = `resource "aws_ebs_volume" "data" {
+  size = 10
+}`
+	tests := []struct {
+		name           string
+		src            string
+		wantSuppressed bool
+	}{
+		{
+			name: "no comment",
+			src:  volume,
+		},
+		{
+			name:           "comment above the block",
+			src:            "# terraform-assistant:ignore TFA003\n" + volume,
+			wantSuppressed: true,
+		},
+		{
+			name:           "lower case id in a list",
+			src:            "// terraform-assistant:ignore tfa001, tfa003\n" + volume,
+			wantSuppressed: true,
+		},
+		{
+			name: "ALL is not a wildcard",
+			src:  "# terraform-assistant:ignore ALL\n" + volume,
+		},
+		{
+			name: "other rule",
+			src:  "# terraform-assistant:ignore TFA001\n" + volume,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings, err := Lint("main.tf", []byte(tt.src))
+			if err != nil {
+				t.Fatalf("Lint() error = %v", err)
+			}
+			if len(findings) != 1 {
+				t.Fatalf("Lint() = %v, want 1 finding", findings)
+			}
+			if findings[0].Suppressed != tt.wantSuppressed {
+				t.Errorf("Suppressed = %v, want %v", findings[0].Suppressed, tt.wantSuppressed)
+			}
+			blocking, suppressed := Blocking(findings, SeverityHigh), SuppressedBlocking(findings, SeverityHigh)
+			if tt.wantSuppressed && (len(blocking) != 0 || len(suppressed) != 1) {
+				t.Errorf("Blocking() = %v, SuppressedBlocking() = %v, want only a suppressed finding", blocking, suppressed)
+			}
+			if !tt.wantSuppressed && (len(blocking) != 1 || len(suppressed) != 0) {
+				t.Errorf("Blocking() = %v, SuppressedBlocking() = %v, want only a blocking finding", blocking, suppressed)
+			}
+			if got := SuppressedBlocking(findings, SeverityCritical); len(got) != 0 {
+				t.Errorf("SuppressedBlocking(critical) = %v, want none", got)
+			}
+		})
+	}
+}
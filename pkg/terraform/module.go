@@ -0,0 +1,201 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-exec/tfexec"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/pkg/errors"
+)
+
+// ErrModule is returned for a module that is invalid or cannot be written
+var ErrModule = errors.New("invalid module")
+
+// ModuleFiles are the configuration files of a module layout
+var ModuleFiles = []string{"main.tf", "variables.tf", "outputs.tf", "versions.tf"}
+
+// Output is an output value declared in the configuration
+type Output struct {
+	Name        string
+	Description string
+	Sensitive   bool
+}
+
+// Outputs returns all the outputs declared in the .tf files of dir
+//
+//	@param dir
+//	@return []Output
+//	@return error
+func Outputs(dir string) ([]Output, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing tf files:%w", err)
+	}
+	var outputs []Output
+	for _, file := range files {
+		body, _, err := parseFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, block := range childBlocks(body, "output") {
+			if len(block.Labels) != 1 {
+				continue
+			}
+			o := Output{Name: block.Labels[0]}
+			o.Sensitive, _ = attrBool(block.Body, "sensitive")
+			o.Description, _ = attrString(block.Body, "description")
+			outputs = append(outputs, o)
+		}
+	}
+	sort.Slice(outputs, func(i, j int) bool { return outputs[i].Name < outputs[j].Name })
+	return outputs, nil
+}
+
+// ModuleReadme renders the README of the module in dir with a usage example and the
+// inputs and outputs tables
+//
+//	@param name
+//	@param description
+//	@param dir
+//	@return string
+//	@return error
+func ModuleReadme(name string, description string, dir string) (string, error) {
+	variables, err := Variables(dir)
+	if err != nil {
+		return "", err
+	}
+	outputs, err := Outputs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", name)
+	if description = strings.TrimSpace(description); description != "" {
+		fmt.Fprintf(&b, "%s\n\n", description)
+	}
+
+	b.WriteString("## Usage\n\n```hcl\n")
+	fmt.Fprintf(&b, "module %q {\n  source = \"./%s\"\n", name, name)
+	for _, v := range variables {
+		if !v.HasDefault {
+			fmt.Fprintf(&b, "\n  %s = ...", v.Name)
+		}
+	}
+	b.WriteString("\n}\n```\n\n")
+
+	b.WriteString("## Inputs\n\n")
+	if len(variables) == 0 {
+		b.WriteString("No inputs.\n\n")
+	} else {
+		b.WriteString("| Name | Description | Type | Default | Required |\n|------|-------------|------|---------|:--------:|\n")
+		for _, v := range variables {
+			def, required := "n/a", "yes"
+			if v.HasDefault {
+				def, required = "`"+markdownCell(v.Default)+"`", "no"
+			}
+			typ := v.Type
+			if typ == "" {
+				typ = "any"
+			}
+			fmt.Fprintf(&b, "| %s | %s | `%s` | %s | %s |\n", v.Name, markdownCell(v.Description), markdownCell(typ), def, required)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Outputs\n\n")
+	if len(outputs) == 0 {
+		b.WriteString("No outputs.\n")
+	} else {
+		b.WriteString("| Name | Description | Sensitive |\n|------|-------------|:---------:|\n")
+		for _, o := range outputs {
+			sensitive := "no"
+			if o.Sensitive {
+				sensitive = "yes"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", o.Name, markdownCell(o.Description), sensitive)
+		}
+	}
+	return b.String(), nil
+}
+
+// ValidateModule copies the .tf files of dir into a temporary directory and runs
+// terraform init without a backend and terraform validate there, so the module is
+// validated in isolation of the working dir
+//
+//	@param ctx
+//	@param dir
+//	@param execDir
+//	@return error
+func ValidateModule(ctx context.Context, dir string, execDir string) error {
+	tmp, err := os.MkdirTemp("", "terraform-assistant-module-")
+	if err != nil {
+		return fmt.Errorf("error creating temp dir:%w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return fmt.Errorf("error listing tf files:%w", err)
+	}
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("error reading %s:%w", file, err)
+		}
+		if err = os.WriteFile(filepath.Join(tmp, filepath.Base(file)), src, 0o600); err != nil {
+			return fmt.Errorf("error copying %s:%w", file, err)
+		}
+	}
+
+	tf, err := tfexec.NewTerraform(tmp, execDir)
+	if err != nil {
+		return fmt.Errorf("error running NewTerraform:%w", err)
+	}
+	tf.SetStdout(io.Discard)
+	if err = tf.Init(ctx, tfexec.Backend(false)); err != nil {
+		return errors.Wrapf(ErrModule, "terraform init failed: %s", err)
+	}
+	out, err := tf.Validate(ctx)
+	if err != nil {
+		return fmt.Errorf("error running terraform validate:%w", err)
+	}
+	if out.Valid {
+		return nil
+	}
+	return errors.Wrapf(ErrModule, "terraform validate failed:\n%s", FormatDiagnostics(out.Diagnostics))
+}
+
+// FormatDiagnostics formats the diagnostics of terraform validate, one per line with their location
+//
+//	@param diags
+//	@return string
+func FormatDiagnostics(diags []tfjson.Diagnostic) string {
+	problems := make([]string, 0, len(diags))
+	for _, d := range diags {
+		problem := fmt.Sprintf("%s: %s", d.Severity, d.Summary)
+		if d.Range != nil {
+			problem += fmt.Sprintf(" (%s:%d)", d.Range.Filename, d.Range.Start.Line)
+		}
+		if d.Detail != "" {
+			problem += ": " + d.Detail
+		}
+		problems = append(problems, problem)
+	}
+	return strings.Join(problems, "\n")
+}
+
+// markdownCell escapes a value for a markdown table cell
+//
+//	@param s
+//	@return string
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -1,7 +1,24 @@
 package terraform
 
+import (
+	"context"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
 // Ops interface for the operation
 type Ops interface {
-	Apply() error
-	Init() error
+	Apply(ctx context.Context) error
+	Init(ctx context.Context, opts InitOptions) error
+	Plan(ctx context.Context) (*tfjson.Plan, error)
+	ShowPlan(ctx context.Context, planFile string) (*tfjson.Plan, error)
+	GenerateConfig(ctx context.Context, out string) (*tfjson.Plan, error)
+	Drift(ctx context.Context) (*tfjson.Plan, error)
+	Destroy(ctx context.Context, targets []string) error
+	StateRm(ctx context.Context, addresses []string) error
+	State(ctx context.Context) (*tfjson.State, error)
+	Validate(ctx context.Context) (*tfjson.ValidateOutput, error)
+	ProviderSchemas(ctx context.Context) (*tfjson.ProviderSchemas, error)
+	SetVar(name string, value string)
+	LastFailure() Failure
 }
@@ -0,0 +1,215 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// defaultLogDir is the directory, relative to the working dir, holding the run logs
+const defaultLogDir = ".terraform-assistant/logs"
+
+var errInterrupted = errors.New("operation interrupted")
+
+// opLog streams the output of a terraform command and copies it into the run log
+type opLog struct {
+	ter    *Terraform
+	file   *os.File
+	events io.WriteCloser
+	// json is the stdout of the commands run with -json, it feeds the events and copies them into the run log
+	json io.Writer
+	// stdout and stderr are the writers of the command, for the commands run without tfexec
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// Failure is the error output of the last terraform command
+type Failure struct {
+	// Output holds the stderr and the error diagnostics of the command
+	Output string
+	// Files are the configuration files the diagnostics point to
+	Files []string
+}
+
+// failureRecorder collects the error output of a command
+type failureRecorder struct {
+	mu     sync.Mutex
+	output strings.Builder
+	files  []string
+}
+
+// Write records stderr
+//
+//	@receiver r
+//	@param p
+//	@return int
+//	@return error
+func (r *failureRecorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.output.Write(p)
+}
+
+// record keeps the error diagnostics of the event stream
+//
+//	@receiver r
+//	@param event
+func (r *failureRecorder) record(event Event) {
+	if event.Type != EventDiagnostic || event.Diagnostic == nil || event.Diagnostic.Severity != "error" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d := event.Diagnostic
+	fmt.Fprintf(&r.output, "Error: %s\n%s\n", d.Summary, d.Detail)
+	if d.Range != nil && d.Range.Filename != "" {
+		fmt.Fprintf(&r.output, "  on %s line %d\n", d.Range.Filename, d.Range.Start.Line)
+		if !slices.Contains(r.files, d.Range.Filename) {
+			r.files = append(r.files, d.Range.Filename)
+		}
+	}
+}
+
+// LastFailure returns the error output of the last terraform command
+//
+//	@receiver t
+//	@return Failure
+func (t *Terraform) LastFailure() Failure {
+	if t.failure == nil {
+		return Failure{}
+	}
+	t.failure.mu.Lock()
+	defer t.failure.mu.Unlock()
+	return Failure{Output: t.failure.output.String(), Files: slices.Clone(t.failure.files)}
+}
+
+// WithOutput is an option that sets where the live terraform output is written, nil hides it.
+//
+//	@param w
+//	@return Option
+func WithOutput(w io.Writer) Option {
+	return func(t *Terraform) error {
+		t.Output = w
+		return nil
+	}
+}
+
+// WithLogDir is an option that sets the directory of the run logs.
+//
+//	@param dir
+//	@return Option
+func WithLogDir(dir string) Option {
+	return func(t *Terraform) error {
+		t.LogDir = dir
+		return nil
+	}
+}
+
+// WithEvents is an option that runs plan and apply with -json and passes the events to the handler.
+//
+//	@param handler
+//	@return Option
+func WithEvents(handler EventHandler) Option {
+	return func(t *Terraform) error {
+		t.Events = handler
+		return nil
+	}
+}
+
+// Interrupted reports whether the operation failed because it was interrupted or timed out,
+// not because of the configuration
+//
+//	@param err
+//	@return bool
+func Interrupted(err error) bool {
+	return errors.Is(err, errInterrupted) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// LogPath returns the path of the log file of this run, empty until a command has run
+//
+//	@receiver t
+//	@return string
+func (t *Terraform) LogPath() string {
+	return t.logPath
+}
+
+// startOp wires the terraform stdout and stderr to the live output and the run log
+//
+//	@receiver t
+//	@param op
+//	@return *opLog
+//	@return error
+func (t *Terraform) startOp(op string) (*opLog, error) {
+	if t.logPath == "" {
+		dir := t.LogDir
+		if dir == "" {
+			dir = filepath.Join(t.WorkingDir, defaultLogDir)
+		}
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, fmt.Errorf("error creating log dir:%w", err)
+		}
+		t.logPath = filepath.Join(dir, time.Now().Format("20060102-150405")+".log")
+	}
+	file, err := os.OpenFile(t.logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("error opening log file:%w", err)
+	}
+	fmt.Fprintf(file, "==> terraform %s (%s)\n", op, time.Now().Format(time.RFC3339))
+	l := &opLog{ter: t, file: file}
+	t.failure = &failureRecorder{}
+	stdout, stderr := io.Writer(file), io.MultiWriter(file, t.failure)
+	if t.Output != nil {
+		stdout = io.MultiWriter(t.Output, file)
+		stderr = io.MultiWriter(t.Output, file, t.failure)
+	}
+	if t.Events != nil {
+		// the raw JSON only goes to the log, the handler renders it
+		stdout = file
+		l.events = NewEventWriter(EventHandlerFunc(func(event Event) {
+			t.failure.record(event)
+			t.Events.HandleEvent(event)
+		}))
+		l.json = io.MultiWriter(file, l.events)
+	}
+	l.stdout, l.stderr = stdout, stderr
+	t.Exec.SetStdout(stdout)
+	t.Exec.SetStderr(stderr)
+	return l, nil
+}
+
+// done detaches the writers and closes the log, wrapping a failure with the log location
+// and whether it was caused by an interrupt or a timeout
+//
+//	@receiver l
+//	@param ctx
+//	@param op
+//	@param err
+//	@return error
+func (l *opLog) done(ctx context.Context, op string, err error) error {
+	if l.events != nil {
+		l.events.Close()
+	}
+	l.ter.Exec.SetStdout(io.Discard)
+	l.ter.Exec.SetStderr(io.Discard)
+	if err != nil {
+		fmt.Fprintf(l.file, "==> terraform %s failed: %s\n", op, strings.TrimSpace(err.Error()))
+	}
+	l.file.Close()
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return errors.Wrapf(errInterrupted, "terraform %s timed out (full log in %s): %s", op, l.ter.logPath, err)
+	case errors.Is(ctx.Err(), context.Canceled):
+		return errors.Wrapf(errInterrupted, "terraform %s was interrupted (full log in %s): %s", op, l.ter.logPath, err)
+	}
+	return fmt.Errorf("error running %s (full log in %s):%w", op, l.ter.logPath, err)
+}
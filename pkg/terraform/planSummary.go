@@ -0,0 +1,179 @@
+package terraform
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// statefulTypes are fragments of resource types that hold data which is lost when they are destroyed
+var statefulTypes = []string{
+	"db_instance", "rds_cluster", "database", "sql", "dynamodb_table", "s3_bucket", "storage_account",
+	"storage_bucket", "ebs_volume", "managed_disk", "compute_disk", "efs_file_system", "elasticache",
+	"redshift", "cosmosdb", "key_vault", "kms_key", "bigquery", "spanner", "documentdb", "docdb",
+}
+
+// PlannedChange is the condensed change of a single resource in a plan
+type PlannedChange struct {
+	Address string
+	Type    string
+	// Action is one of create, update, delete, replace, read or import
+	Action string
+	// Import is set when the resource is imported by the plan
+	Import bool
+	// ReplacePaths are the attributes forcing a replacement, e.g. "ami" or "tags.Name"
+	ReplacePaths []string
+	// DataLoss is set when a stateful resource is deleted or replaced
+	DataLoss bool
+}
+
+// String formats the change as a single line with the terraform action symbol
+//
+//	@receiver c
+//	@return string
+func (c PlannedChange) String() string {
+	line := fmt.Sprintf("%-3s %s (%s)", actionSymbols[c.Action], c.Address, c.Action)
+	if len(c.ReplacePaths) > 0 {
+		line += fmt.Sprintf(" forces replacement: %s", strings.Join(c.ReplacePaths, ", "))
+	}
+	if c.Import && c.Action != "import" {
+		line += " [import]"
+	}
+	if c.DataLoss {
+		line += " [possible data loss]"
+	}
+	return line
+}
+
+// SummarizePlan returns the changes of the plan that are not no-ops, destructive changes first
+//
+//	@param plan
+//	@return []PlannedChange
+func SummarizePlan(plan *tfjson.Plan) []PlannedChange {
+	var changes []PlannedChange
+	for _, rc := range plan.ResourceChanges {
+		if rc.Change == nil || (rc.Change.Actions.NoOp() && rc.Change.Importing == nil) {
+			continue
+		}
+		c := PlannedChange{
+			Address: rc.Address,
+			Type:    rc.Type,
+			Action:  planAction(rc.Change.Actions),
+			Import:  rc.Change.Importing != nil,
+		}
+		if c.Import && rc.Change.Actions.NoOp() {
+			c.Action = "import"
+		}
+		for _, path := range rc.Change.ReplacePaths {
+			c.ReplacePaths = append(c.ReplacePaths, formatPath(path))
+		}
+		if c.Action == "delete" || c.Action == "replace" {
+			c.DataLoss = isStateful(rc.Type)
+		}
+		changes = append(changes, c)
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return actionRank(changes[i].Action) < actionRank(changes[j].Action)
+	})
+	return changes
+}
+
+// FormatChanges renders the changes one per line followed by the totals
+//
+//	@param changes
+//	@return string
+func FormatChanges(changes []PlannedChange) string {
+	if len(changes) == 0 {
+		return "No changes."
+	}
+	counts := map[string]int{}
+	lines := make([]string, 0, len(changes)+1)
+	for _, c := range changes {
+		counts[c.Action]++
+		lines = append(lines, c.String())
+	}
+	total := fmt.Sprintf("Total: %d to create, %d to update, %d to replace, %d to delete.",
+		counts["create"], counts["update"], counts["replace"], counts["delete"])
+	if counts["import"] > 0 {
+		total = fmt.Sprintf("Total: %d to import, %s", counts["import"], strings.TrimPrefix(total, "Total: "))
+	}
+	lines = append(lines, total)
+	return strings.Join(lines, "\n")
+}
+
+// planAction converts the plan actions into a single action name
+//
+//	@param actions
+//	@return string
+func planAction(actions tfjson.Actions) string {
+	switch {
+	case actions.Replace():
+		return "replace"
+	case actions.Create():
+		return "create"
+	case actions.Update():
+		return "update"
+	case actions.Delete():
+		return "delete"
+	case actions.Read():
+		return "read"
+	}
+	return "noop"
+}
+
+// actionRank orders the most destructive actions first
+//
+//	@param action
+//	@return int
+func actionRank(action string) int {
+	switch action {
+	case "delete":
+		return 0
+	case "replace":
+		return 1
+	case "update":
+		return 2
+	case "create":
+		return 3
+	}
+	return 4
+}
+
+// formatPath joins a replace path like ["tags", "Name"] or ["ingress", 0] into tags.Name or ingress[0]
+//
+//	@param path
+//	@return string
+func formatPath(path interface{}) string {
+	steps, ok := path.([]interface{})
+	if !ok {
+		return fmt.Sprint(path)
+	}
+	var b strings.Builder
+	for _, step := range steps {
+		switch s := step.(type) {
+		case string:
+			if b.Len() > 0 {
+				b.WriteString(".")
+			}
+			b.WriteString(s)
+		default:
+			fmt.Fprintf(&b, "[%v]", s)
+		}
+	}
+	return b.String()
+}
+
+// isStateful reports whether the resource type holds data
+//
+//	@param resourceType
+//	@return bool
+func isStateful(resourceType string) bool {
+	for _, fragment := range statefulTypes {
+		if strings.Contains(resourceType, fragment) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,216 @@
+package terraform
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/pkg/errors"
+)
+
+// policyExt is the file extension of CEL policy files
+const policyExt = ".cel"
+
+var errPolicy = errors.New("policy check failed")
+
+// Policy evaluates a plan before it is applied
+type Policy interface {
+	// Evaluate returns the violations found in the plan, any violation blocks apply
+	Evaluate(plan *tfjson.Plan) ([]PolicyViolation, error)
+}
+
+// PolicyViolation is a deny result of a policy for a resource change
+type PolicyViolation struct {
+	Policy  string
+	Address string
+	Message string
+}
+
+// String formats the violation for the terminal
+//
+//	@receiver v
+//	@return string
+func (v PolicyViolation) String() string {
+	return fmt.Sprintf("[%s] %s: %s", v.Policy, v.Address, v.Message)
+}
+
+// celRule is a single compiled deny expression
+type celRule struct {
+	name    string
+	message string
+	program cel.Program
+}
+
+// CELPolicy evaluates CEL deny expressions against every resource change of a plan.
+// Each expression can use `resource` (an entry of the plan JSON resource_changes)
+// and `plan` (the whole plan JSON) and must return true to deny the change.
+type CELPolicy struct {
+	rules []celRule
+}
+
+// LoadPolicies loads and compiles the .cel files found in the given files or directories, finding
+// none is an error. The name of a policy is its file name, the leading // comment lines are its message.
+//
+//	@param paths
+//	@return *CELPolicy
+//	@return error
+func LoadPolicies(paths []string) (*CELPolicy, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("resource", cel.DynType),
+		cel.Variable("plan", cel.DynType),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cel env:%w", err)
+	}
+	files, err := policyFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+	// an empty policy would let every apply pass, a mistyped path must not disable the gate
+	if len(files) == 0 {
+		return nil, errors.Wrapf(errPolicy, "no %s policy files found in %s", policyExt, strings.Join(paths, ", "))
+	}
+	policy := &CELPolicy{}
+	for _, file := range files {
+		message, expr, err := readPolicyFile(file)
+		if err != nil {
+			return nil, err
+		}
+		ast, issues := env.Compile(expr)
+		if issues != nil && issues.Err() != nil {
+			return nil, errors.Wrapf(errPolicy, "error compiling %s: %s", file, issues.Err())
+		}
+		if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+			return nil, errors.Wrapf(errPolicy, "policy %s must return a bool but returns %s", file, ast.OutputType())
+		}
+		program, err := env.Program(ast)
+		if err != nil {
+			return nil, fmt.Errorf("error building program for %s:%w", file, err)
+		}
+		name := strings.TrimSuffix(filepath.Base(file), policyExt)
+		if message == "" {
+			message = "denied by policy " + name
+		}
+		policy.rules = append(policy.rules, celRule{name: name, message: message, program: program})
+	}
+	return policy, nil
+}
+
+// Evaluate runs every rule against every resource change of the plan
+//
+//	@receiver p
+//	@param plan
+//	@return []PolicyViolation
+//	@return error
+func (p *CELPolicy) Evaluate(plan *tfjson.Plan) ([]PolicyViolation, error) {
+	raw, err := json.Marshal(plan)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding plan:%w", err)
+	}
+	var planMap map[string]interface{}
+	if err = json.Unmarshal(raw, &planMap); err != nil {
+		return nil, fmt.Errorf("error decoding plan:%w", err)
+	}
+	changes, _ := planMap["resource_changes"].([]interface{})
+	var violations []PolicyViolation
+	for _, change := range changes {
+		resource, ok := change.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		address, _ := resource["address"].(string)
+		for _, rule := range p.rules {
+			out, _, err := rule.program.Eval(map[string]interface{}{
+				"resource": resource,
+				"plan":     planMap,
+			})
+			if err != nil {
+				return nil, errors.Wrapf(errPolicy, "error evaluating policy %s for %s: %s", rule.name, address, err)
+			}
+			deny, ok := out.Value().(bool)
+			if !ok {
+				return nil, errors.Wrapf(errPolicy, "policy %s returned %v instead of a bool", rule.name, out.Value())
+			}
+			if deny {
+				violations = append(violations, PolicyViolation{Policy: rule.name, Address: address, Message: rule.message})
+			}
+		}
+	}
+	return violations, nil
+}
+
+// policyFiles expands directories into the .cel files they contain
+//
+//	@param paths
+//	@return []string
+//	@return error
+func policyFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading policy path:%w", err)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(path, "*"+policyExt))
+		if err != nil {
+			return nil, fmt.Errorf("error listing policies:%w", err)
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// readPolicyFile splits a policy file into its leading comment message and its expression
+//
+//	@param file
+//	@return string
+//	@return string
+//	@return error
+func readPolicyFile(file string) (string, string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", "", fmt.Errorf("error opening policy:%w", err)
+	}
+	defer f.Close()
+	var message []string
+	var expr strings.Builder
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if expr.Len() == 0 && strings.HasPrefix(trimmed, "//") {
+			message = append(message, strings.TrimSpace(strings.TrimPrefix(trimmed, "//")))
+			continue
+		}
+		expr.WriteString(line + "\n")
+	}
+	if err = scanner.Err(); err != nil {
+		return "", "", fmt.Errorf("error reading policy:%w", err)
+	}
+	return strings.Join(message, " "), expr.String(), nil
+}
+
+// formatViolations renders the violations one per line
+//
+//	@param violations
+//	@return string
+func formatViolations(violations []PolicyViolation) string {
+	lines := make([]string, 0, len(violations))
+	for _, v := range violations {
+		lines = append(lines, "  - "+v.String())
+	}
+	return strings.Join(lines, "\n")
+}
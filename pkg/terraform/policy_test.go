@@ -0,0 +1,146 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/pkg/errors"
+)
+
+// writePolicies writes the policy files into a temporary dir and returns it
+func writePolicies(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// testPlan returns a plan with a change of the given actions for every address
+func testPlan(actions tfjson.Actions, after map[string]interface{}, addresses ...string) *tfjson.Plan {
+	plan := &tfjson.Plan{FormatVersion: "1.2"}
+	for _, address := range addresses {
+		typ, _, _ := strings.Cut(address, ".")
+		plan.ResourceChanges = append(plan.ResourceChanges, &tfjson.ResourceChange{
+			Address: address,
+			Type:    typ,
+			Change:  &tfjson.Change{Actions: actions, After: after},
+		})
+	}
+	return plan
+}
+
+func TestCELPolicyEvaluate(t *testing.T) {
+	const noDelete = "// deleting is not allowed\n'delete' in resource.change.actions\n"
+	const noPublic = "resource.type == 'aws_s3_bucket' && has(resource.change.after.acl) && resource.change.after.acl == 'public-read'\n"
+	tests := []struct {
+		name string
+		plan *tfjson.Plan
+		want []PolicyViolation
+	}{
+		{
+			name: "create allowed",
+			plan: testPlan(tfjson.Actions{tfjson.ActionCreate}, map[string]interface{}{"acl": "private"}, "aws_s3_bucket.logs"),
+		},
+		{
+			name: "delete denied with the comment as message",
+			plan: testPlan(tfjson.Actions{tfjson.ActionDelete}, nil, "aws_s3_bucket.logs", "aws_instance.web"),
+			want: []PolicyViolation{
+				{Policy: "no_delete", Address: "aws_s3_bucket.logs", Message: "deleting is not allowed"},
+				{Policy: "no_delete", Address: "aws_instance.web", Message: "deleting is not allowed"},
+			},
+		},
+		{
+			name: "public bucket denied with the default message",
+			plan: testPlan(tfjson.Actions{tfjson.ActionCreate}, map[string]interface{}{"acl": "public-read"}, "aws_s3_bucket.site"),
+			want: []PolicyViolation{
+				{Policy: "no_public", Address: "aws_s3_bucket.site", Message: "denied by policy no_public"},
+			},
+		},
+		{
+			name: "no changes",
+			plan: &tfjson.Plan{FormatVersion: "1.2"},
+		},
+	}
+	dir := writePolicies(t, map[string]string{"no_delete.cel": noDelete, "no_public.cel": noPublic, "README.md": "not a policy"})
+	policy, err := LoadPolicies([]string{dir})
+	if err != nil {
+		t.Fatalf("LoadPolicies() error = %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := policy.Evaluate(tt.plan)
+			if err != nil {
+				t.Fatalf("Evaluate() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadPolicies(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		paths   func(dir string) []string
+		wantErr error
+	}{
+		{
+			name:  "directory",
+			files: map[string]string{"a.cel": "false"},
+			paths: func(dir string) []string { return []string{dir} },
+		},
+		{
+			name:  "file",
+			files: map[string]string{"a.cel": "false"},
+			paths: func(dir string) []string { return []string{filepath.Join(dir, "a.cel")} },
+		},
+		{
+			name:    "directory without policies",
+			files:   map[string]string{"a.rego": "package main"},
+			paths:   func(dir string) []string { return []string{dir} },
+			wantErr: errPolicy,
+		},
+		{
+			name:    "only blank paths",
+			paths:   func(string) []string { return []string{" ", ""} },
+			wantErr: errPolicy,
+		},
+		{
+			name:    "expression not returning a bool",
+			files:   map[string]string{"a.cel": "'deny'"},
+			paths:   func(dir string) []string { return []string{dir} },
+			wantErr: errPolicy,
+		},
+		{
+			name:    "invalid expression",
+			files:   map[string]string{"a.cel": "resource.type =="},
+			paths:   func(dir string) []string { return []string{dir} },
+			wantErr: errPolicy,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writePolicies(t, tt.files)
+			_, err := LoadPolicies(tt.paths(dir))
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("LoadPolicies() error = %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("LoadPolicies() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+	if _, err := LoadPolicies([]string{filepath.Join(t.TempDir(), "missing")}); err == nil {
+		t.Error("LoadPolicies() of a missing path succeeded")
+	}
+}
@@ -0,0 +1,185 @@
+package terraform
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Status of a resource in the progress view
+const (
+	statusPlanned  = "planned"
+	statusRunning  = "running"
+	statusComplete = "complete"
+	statusErrored  = "errored"
+)
+
+// actionSymbols are the symbols terraform uses for the planned actions
+var actionSymbols = map[string]string{
+	"create":  "+",
+	"update":  "~",
+	"delete":  "-",
+	"replace": "-/+",
+	"read":    "<=",
+	"noop":    " ",
+	"move":    "->",
+	"import":  "<-",
+}
+
+// ResourceProgress is the progress of a single resource
+type ResourceProgress struct {
+	Address string
+	Action  string
+	Status  string
+	Started time.Time
+	Elapsed time.Duration
+	ID      string
+}
+
+// Progress is an EventHandler that renders one line per resource event and a summary table
+type Progress struct {
+	mu        sync.Mutex
+	w         io.Writer
+	resources map[string]*ResourceProgress
+	order     []string
+	summary   *ChangeSummary
+}
+
+// NewProgress returns a progress view writing to w
+//
+//	@param w
+//	@return *Progress
+func NewProgress(w io.Writer) *Progress {
+	return &Progress{w: w, resources: map[string]*ResourceProgress{}}
+}
+
+// HandleEvent updates the resource state and prints a progress line
+//
+//	@receiver p
+//	@param event
+func (p *Progress) HandleEvent(event Event) {
+	if !event.hasPayload() {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch event.Type {
+	case EventPlannedChange:
+		res := p.resource(event.Change.Resource.Addr)
+		res.Action, res.Status = event.Change.Action, statusPlanned
+		reason := ""
+		if event.Change.Reason != "" {
+			reason = fmt.Sprintf(" (%s)", strings.ReplaceAll(event.Change.Reason, "_", " "))
+		}
+		fmt.Fprintf(p.w, "  %-3s %s: planned %s%s\n", actionSymbols[res.Action], res.Address, res.Action, reason)
+	case EventApplyStart:
+		res := p.resource(event.Hook.Resource.Addr)
+		res.Action, res.Status, res.Started = event.Hook.Action, statusRunning, event.Timestamp
+		fmt.Fprintf(p.w, "  ⏳  %s: %s started\n", res.Address, res.Action)
+	case EventApplyProgress:
+		res := p.resource(event.Hook.Resource.Addr)
+		res.Elapsed = seconds(event.Hook.Elapsed)
+		fmt.Fprintf(p.w, "  ⏳  %s: still running %s (%s)\n", res.Address, res.Action, res.Elapsed)
+	case EventApplyComplete:
+		res := p.resource(event.Hook.Resource.Addr)
+		res.Status, res.Elapsed = statusComplete, seconds(event.Hook.Elapsed)
+		if event.Hook.IDValue != "" {
+			res.ID = fmt.Sprintf("%s=%s", event.Hook.IDKey, event.Hook.IDValue)
+		}
+		fmt.Fprintf(p.w, "  ✅  %s: %s complete after %s\n", res.Address, res.Action, res.Elapsed)
+	case EventApplyErrored:
+		res := p.resource(event.Hook.Resource.Addr)
+		res.Status, res.Elapsed = statusErrored, seconds(event.Hook.Elapsed)
+		fmt.Fprintf(p.w, "  ❌  %s: %s failed after %s\n", res.Address, res.Action, res.Elapsed)
+	case EventDiagnostic:
+		d := event.Diagnostic
+		fmt.Fprintf(p.w, "  %s %s: %s\n", diagnosticSymbol(d.Severity), d.Summary, d.Detail)
+	case EventChangeSummary:
+		p.summary = event.Changes
+		if event.Changes.Operation == "apply" || event.Changes.Operation == "destroy" {
+			p.writeSummary()
+			return
+		}
+		fmt.Fprintf(p.w, "  Plan: %d to add, %d to change, %d to destroy.\n", event.Changes.Add, event.Changes.Change, event.Changes.Remove)
+	case EventText:
+		fmt.Fprintln(p.w, event.Message)
+	}
+}
+
+// Resources returns the progress of every resource seen so far in event order
+//
+//	@receiver p
+//	@return []ResourceProgress
+func (p *Progress) Resources() []ResourceProgress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]ResourceProgress, 0, len(p.order))
+	for _, addr := range p.order {
+		out = append(out, *p.resources[addr])
+	}
+	return out
+}
+
+// Summary returns the last change summary, nil until terraform reports one
+//
+//	@receiver p
+//	@return *ChangeSummary
+func (p *Progress) Summary() *ChangeSummary {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.summary
+}
+
+// resource returns the progress of the address, creating it when needed
+//
+//	@receiver p
+//	@param addr
+//	@return *ResourceProgress
+func (p *Progress) resource(addr string) *ResourceProgress {
+	res, ok := p.resources[addr]
+	if !ok {
+		res = &ResourceProgress{Address: addr}
+		p.resources[addr] = res
+		p.order = append(p.order, addr)
+	}
+	return res
+}
+
+// writeSummary prints the table of resources and the added/changed/destroyed totals
+//
+//	@receiver p
+func (p *Progress) writeSummary() {
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\n  RESOURCE\tACTION\tSTATUS\tELAPSED\tID")
+	for _, addr := range p.order {
+		res := p.resources[addr]
+		if res.Action == "noop" || res.Action == "read" {
+			continue
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\n", res.Address, res.Action, res.Status, res.Elapsed, res.ID)
+	}
+	tw.Flush()
+	fmt.Fprintf(p.w, "  %s complete: %d added, %d changed, %d destroyed.\n", p.summary.Operation, p.summary.Add, p.summary.Change, p.summary.Remove)
+}
+
+// seconds converts terraform elapsed seconds to a duration
+//
+//	@param s
+//	@return time.Duration
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second)).Round(time.Second)
+}
+
+// diagnosticSymbol returns the symbol of a diagnostic severity
+//
+//	@param severity
+//	@return string
+func diagnosticSymbol(severity string) string {
+	if severity == "error" {
+		return "❌ Error:"
+	}
+	return "⚠️  Warning:"
+}
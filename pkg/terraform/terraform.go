@@ -2,8 +2,13 @@ package terraform
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"time"
 
 	"github.com/hashicorp/terraform-exec/tfexec"
+	tfjson "github.com/hashicorp/terraform-json"
 )
 
 // Terraform structure
@@ -11,22 +16,137 @@ type Terraform struct {
 	WorkingDir string
 	ExecDir    string
 	Exec       *tfexec.Terraform
+	Policy     Policy
+	Vars       []string
+	VarFiles   []string
+	// Output receives the live terraform output
+	Output io.Writer
+	// Timeouts limit the duration of each operation
+	Timeouts Timeouts
+	// Events receives the machine readable UI events of plan and apply instead of the raw output
+	Events EventHandler
+	// LogDir holds a log file with the full terraform output of each run
+	LogDir string
+	// PlanHandler receives the plan before every apply, an error stops the apply
+	PlanHandler func(plan *tfjson.Plan) error
+	logPath     string
+	failure     *failureRecorder
+	// interruptGrace is the wait delay of the commands run without tfexec
+	interruptGrace time.Duration
+}
+
+// Timeouts are the maximum durations of the terraform operations, zero means no limit
+type Timeouts struct {
+	Init  time.Duration
+	Plan  time.Duration
+	Apply time.Duration
+}
+
+// Option are options that can be passed when creating a new terraform instance.
+type Option func(*Terraform) error
+
+// WithPolicy is an option that evaluates the policy against the plan before every apply.
+//
+//	@param policy
+//	@return Option
+func WithPolicy(policy Policy) Option {
+	return func(t *Terraform) error {
+		t.Policy = policy
+		return nil
+	}
+}
+
+// WithPlanHandler is an option that passes the plan to the handler before every apply.
+//
+//	@param handler
+//	@return Option
+func WithPlanHandler(handler func(plan *tfjson.Plan) error) Option {
+	return func(t *Terraform) error {
+		t.PlanHandler = handler
+		return nil
+	}
+}
+
+// WithTimeouts is an option that limits the duration of each operation.
+//
+//	@param timeouts
+//	@return Option
+func WithTimeouts(timeouts Timeouts) Option {
+	return func(t *Terraform) error {
+		t.Timeouts = timeouts
+		return nil
+	}
+}
+
+// WithInterruptGrace is an option that sets how long terraform may take to stop cleanly
+// and release the state lock after an interrupt before it is killed.
+//
+//	@param grace
+//	@return Option
+func WithInterruptGrace(grace time.Duration) Option {
+	return func(t *Terraform) error {
+		if runtime.GOOS == "windows" {
+			// graceful cancellation is not supported on windows, terraform is killed right away
+			return nil
+		}
+		t.interruptGrace = grace
+		return t.Exec.SetWaitDelay(grace)
+	}
+}
+
+// WithVars is an option that passes name=value variable assignments to plan.
+//
+//	@param vars
+//	@return Option
+func WithVars(vars []string) Option {
+	return func(t *Terraform) error {
+		t.Vars = append(t.Vars, vars...)
+		return nil
+	}
+}
+
+// WithVarFiles is an option that passes variable files to plan.
+//
+//	@param varFiles
+//	@return Option
+func WithVarFiles(varFiles []string) Option {
+	return func(t *Terraform) error {
+		t.VarFiles = append(t.VarFiles, varFiles...)
+		return nil
+	}
+}
+
+// SetVar adds a variable assignment used by the next plans
+//
+//	@receiver t
+//	@param name
+//	@param value
+func (t *Terraform) SetVar(name string, value string) {
+	t.Vars = append(t.Vars, name+"="+value)
 }
 
 // NewTerraform creates a new instances of the terraform struct
 //
 //	@param workingDir
 //	@param execDir
+//	@param options
 //	@return *Terraform
 //	@return error
-func NewTerraform(workingDir string, execDir string) (*Terraform, error) {
+func NewTerraform(workingDir string, execDir string, options ...Option) (*Terraform, error) {
 	tf, err := tfexec.NewTerraform(workingDir, execDir)
 	if err != nil {
 		return nil, fmt.Errorf("Error new terraform:%w", err)
 	}
-	return &Terraform{
+	t := &Terraform{
 		WorkingDir: workingDir,
 		ExecDir:    execDir,
 		Exec:       tf,
-	}, nil
+		Output:     os.Stdout,
+	}
+	for _, o := range options {
+		if err := o(t); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
 }
@@ -0,0 +1,147 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// Variable is an input variable declared in the configuration
+type Variable struct {
+	Name        string
+	Type        string
+	Description string
+	Sensitive   bool
+	HasDefault  bool
+	// Default is the source of the default value, e.g. "t3.micro" or ["a", "b"]
+	Default string
+}
+
+// RequiredVariables returns the variables declared in the .tf files of dir that have no default value
+//
+//	@param dir
+//	@return []Variable
+//	@return error
+func RequiredVariables(dir string) ([]Variable, error) {
+	variables, err := Variables(dir)
+	if err != nil {
+		return nil, err
+	}
+	var required []Variable
+	for _, v := range variables {
+		if !v.HasDefault {
+			required = append(required, v)
+		}
+	}
+	return required, nil
+}
+
+// Variables returns all the variables declared in the .tf files of dir
+//
+//	@param dir
+//	@return []Variable
+//	@return error
+func Variables(dir string) ([]Variable, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing tf files:%w", err)
+	}
+	var variables []Variable
+	for _, file := range files {
+		body, src, err := parseFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, block := range childBlocks(body, "variable") {
+			if len(block.Labels) != 1 {
+				continue
+			}
+			v := Variable{Name: block.Labels[0]}
+			if attr, ok := block.Body.Attributes["default"]; ok {
+				v.HasDefault = true
+				v.Default = string(attr.Expr.Range().SliceBytes(src))
+			}
+			v.Sensitive, _ = attrBool(block.Body, "sensitive")
+			v.Description, _ = attrString(block.Body, "description")
+			if attr, ok := block.Body.Attributes["type"]; ok {
+				v.Type = string(attr.Expr.Range().SliceBytes(src))
+			}
+			variables = append(variables, v)
+		}
+	}
+	sort.Slice(variables, func(i, j int) bool { return variables[i].Name < variables[j].Name })
+	return variables, nil
+}
+
+// AutoVarFiles returns the variable files terraform loads automatically from dir
+//
+//	@param dir
+//	@return []string
+func AutoVarFiles(dir string) []string {
+	var files []string
+	for _, name := range []string{"terraform.tfvars", "terraform.tfvars.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			files = append(files, filepath.Join(dir, name))
+		}
+	}
+	for _, pattern := range []string{"*.auto.tfvars", "*.auto.tfvars.json"} {
+		matches, _ := filepath.Glob(filepath.Join(dir, pattern))
+		files = append(files, matches...)
+	}
+	return files
+}
+
+// VarFileNames returns the names of the variables assigned in a .tfvars or .tfvars.json file
+//
+//	@param path
+//	@return []string
+//	@return error
+func VarFileNames(path string) ([]string, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading var file:%w", err)
+	}
+	var names []string
+	if strings.HasSuffix(path, ".json") {
+		values := map[string]json.RawMessage{}
+		if err = json.Unmarshal(src, &values); err != nil {
+			return nil, fmt.Errorf("error decoding var file %s:%w", path, err)
+		}
+		for name := range values {
+			names = append(names, name)
+		}
+		return names, nil
+	}
+	file, diags := hclsyntax.ParseConfig(src, path, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("error parsing var file %s:%w", path, diags)
+	}
+	for name := range file.Body.(*hclsyntax.Body).Attributes {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// parseFile parses a configuration file into its body
+//
+//	@param path
+//	@return *hclsyntax.Body
+//	@return []byte
+//	@return error
+func parseFile(path string) (*hclsyntax.Body, []byte, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading %s:%w", path, err)
+	}
+	file, diags := hclsyntax.ParseConfig(src, path, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, nil, fmt.Errorf("error parsing %s:%w", path, diags)
+	}
+	return file.Body.(*hclsyntax.Body), src, nil
+}
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around a change
+const diffContext = 2
+
+// diffOp is a single line of an edit script
+type diffOp struct {
+	kind byte
+	text string
+	oldN int
+	newN int
+}
+
+// Diff returns a unified diff between two texts, or "" when they are equal
+//
+//	@param oldName
+//	@param newName
+//	@param before
+//	@param after
+//	@return string
+func Diff(oldName string, newName string, before string, after string) string {
+	if before == after {
+		return ""
+	}
+	ops := editScript(splitLines(before), splitLines(after))
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+	for start := 0; start < len(ops); {
+		if ops[start].kind == ' ' {
+			start++
+			continue
+		}
+		// extend the hunk while changes are closer than twice the context
+		from := max(start-diffContext, 0)
+		end := start
+		for i := start; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				end = i
+			} else if i-end > 2*diffContext {
+				break
+			}
+		}
+		to := min(end+diffContext+1, len(ops))
+		oldStart, newStart, oldCount, newCount := ops[from].oldN, ops[from].newN, 0, 0
+		for _, op := range ops[from:to] {
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+		for _, op := range ops[from:to] {
+			fmt.Fprintf(&b, "%c%s\n", op.kind, op.text)
+		}
+		start = to
+	}
+	return b.String()
+}
+
+// editScript computes the line edit script between a and b using the longest common subsequence
+//
+//	@param a
+//	@param b
+//	@return []diffOp
+func editScript(a []string, b []string) []diffOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{kind: ' ', text: a[i], oldN: i + 1, newN: j + 1})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{kind: '-', text: a[i], oldN: i + 1, newN: j + 1})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: '+', text: b[j], oldN: i + 1, newN: j + 1})
+			j++
+		}
+	}
+	return ops
+}
+
+// splitLines splits a text into lines without the trailing empty line
+//
+//	@param s
+//	@return []string
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
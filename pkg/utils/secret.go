@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Redacted replaces the secrets removed by RedactSecrets
+const Redacted = "[REDACTED]"
+
+// SecretMatch is a credential found in a text
+type SecretMatch struct {
+	Kind  string
+	Line  int
+	start int
+	end   int
+}
+
+// String formats the match without the secret value
+//
+//	@receiver m
+//	@return string
+func (m SecretMatch) String() string {
+	return fmt.Sprintf("%s (line %d)", m.Kind, m.Line)
+}
+
+// secretPattern is a credential pattern, group is the sub match holding the secret value
+type secretPattern struct {
+	kind  string
+	re    *regexp.Regexp
+	group int
+	// literal skips values holding a template interpolation or directive, which are references
+	literal bool
+}
+
+// credentialAttributes are the attribute names holding a credential, matched exactly so names
+// such as client_secret_setting_name or password_policy are not taken for credentials
+var credentialAttributes = []string{
+	"password", "passwd", "admin_password", "administrator_login_password", "master_password",
+	"root_password", "db_password", "secret", "client_secret", "api_key", "access_token", "auth_token",
+}
+
+var secretPatterns = []secretPattern{
+	{kind: "AWS access key", re: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{kind: "AWS secret key", re: regexp.MustCompile(`(?i)(?:aws_secret_access_key|secret_key|secret_access_key)["']?\s*[=:]\s*["']?([A-Za-z0-9/+=]{40})\b`), group: 1},
+	{kind: "private key", re: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY(?: BLOCK)?-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY(?: BLOCK)?-----`)},
+	{kind: "GitHub token", re: regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{kind: "OpenAI key", re: regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{32,}\b`)},
+	{kind: "Slack token", re: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`)},
+	{kind: "connection string password", re: regexp.MustCompile(`\b[a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@"']+:([^@\s/"']+)@`), group: 1},
+	{kind: "connection string password", re: regexp.MustCompile(`(?i)\b(?:AccountKey|SharedAccessKey|Password|Pwd)=([^;"'\s]+)`), group: 1},
+	{kind: "hardcoded password", re: regexp.MustCompile(`(?im)(?:^|[^a-z0-9_.])["']?(?:` + strings.Join(credentialAttributes, "|") + `)["']?\s*[=:]\s*"([^"\s][^"]*)"`), group: 1, literal: true},
+}
+
+// FindSecrets returns the credentials found in the text
+//
+//	@param text
+//	@return []SecretMatch
+func FindSecrets(text string) []SecretMatch {
+	var matches []SecretMatch
+	for _, p := range secretPatterns {
+		for _, loc := range p.re.FindAllStringSubmatchIndex(text, -1) {
+			start, end := loc[2*p.group], loc[2*p.group+1]
+			if start < 0 || text[start:end] == Redacted {
+				continue
+			}
+			if p.literal && (strings.Contains(text[start:end], "${") || strings.Contains(text[start:end], "%{")) {
+				continue
+			}
+			if overlaps(matches, start, end) {
+				continue
+			}
+			matches = append(matches, SecretMatch{
+				Kind:  p.kind,
+				Line:  strings.Count(text[:start], "\n") + 1,
+				start: start,
+				end:   end,
+			})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+	return matches
+}
+
+// RedactSecrets replaces the credentials found in the text with Redacted
+//
+//	@param text
+//	@return string
+//	@return []SecretMatch
+func RedactSecrets(text string) (string, []SecretMatch) {
+	matches := FindSecrets(text)
+	if len(matches) == 0 {
+		return text, nil
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(text[last:m.start])
+		b.WriteString(Redacted)
+		last = m.end
+	}
+	b.WriteString(text[last:])
+	return b.String(), matches
+}
+
+// overlaps reports whether the range is already covered by a match
+//
+//	@param matches
+//	@param start
+//	@param end
+//	@return bool
+func overlaps(matches []SecretMatch, start int, end int) bool {
+	for _, m := range matches {
+		if start < m.end && m.start < end {
+			return true
+		}
+	}
+	return false
+}
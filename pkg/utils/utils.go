@@ -1,6 +1,10 @@
 package utils
 
-import "strings"
+import (
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
 
 // ToPtr converts type T to a *T as a convenience.
 //
@@ -17,3 +21,14 @@ func ToPtr[T any](i T) *T {
 func RemoveBlankLinesFromString(input string) string {
 	return strings.TrimLeft(input, "\n\r \t")
 }
+
+// FormatHCL formats the template in the canonical terraform fmt style with a single trailing newline
+//
+//	@param input
+//	@return string
+func FormatHCL(input string) string {
+	input = strings.ReplaceAll(input, "\r\n", "\n")
+	input = strings.TrimSpace(RemoveBlankLinesFromString(input))
+	formatted := string(hclwrite.Format([]byte(input)))
+	return strings.TrimRight(formatted, "\n \t") + "\n"
+}